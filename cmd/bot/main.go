@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/bot"
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+	"github.com/eshahhh/ultimatetictactoe/internal/rating"
+	"github.com/eshahhh/ultimatetictactoe/internal/ugn"
+	"github.com/gorilla/websocket"
+)
+
+type inboundMessage struct {
+	Type    game.MessageType `json:"type"`
+	Payload json.RawMessage  `json:"payload"`
+}
+
+func main() {
+	serverURL := flag.String("server", "ws://localhost:39171/ws", "websocket endpoint of the game server")
+	name := flag.String("name", "", "bot display name (default HumanBot<rating>)")
+	gamesDir := flag.String("games", "games", "directory of archived UGN games to learn from")
+	ratingsFile := flag.String("ratings", "", "optional JSON file mapping player names to ratings")
+	target := flag.Int("rating", rating.DefaultRating, "rating the bot should imitate")
+	depth := flag.Int("depth", 2, "search depth for positions missing from the archive")
+	flag.Parse()
+
+	if *name == "" {
+		*name = fmt.Sprintf("HumanBot%d", *target)
+	}
+
+	games, err := ugn.ParseUGNDir(*gamesDir)
+	if games == nil {
+		log.Fatalf("Failed to load archive: %v", err)
+	}
+	if err != nil {
+		log.Printf("Some archived games could not be read: %v", err)
+	}
+
+	var ratingOf bot.RatingFunc
+	if *ratingsFile != "" {
+		table, err := rating.Load(*ratingsFile)
+		if err != nil {
+			log.Fatalf("Failed to load ratings: %v", err)
+		}
+		ratingOf = table.Lookup
+	}
+
+	stats, err := bot.BuildStats(games, ratingOf, rating.DefaultRating)
+	if err != nil {
+		log.Printf("Some archived games could not be replayed: %v", err)
+	}
+	log.Printf("Learned from %d games, imitating rating %d", stats.GameCount(), *target)
+
+	player := bot.NewHumanBot(stats, *target, time.Now().UnixNano())
+	player.Depth = *depth
+
+	u, err := url.Parse(*serverURL)
+	if err != nil {
+		log.Fatalf("Invalid server URL: %v", err)
+	}
+	q := u.Query()
+	q.Set("name", *name)
+	u.RawQuery = q.Encode()
+
+	conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	if err != nil {
+		log.Fatal("Failed to connect to server:", err)
+	}
+	defer conn.Close()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			log.Println("Error reading message:", err)
+			return
+		}
+
+		var msg inboundMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			log.Printf("Server: %s", string(data))
+			continue
+		}
+
+		switch msg.Type {
+		case game.MessageTypeGameState:
+			var state game.GameStatePayload
+			if err := json.Unmarshal(msg.Payload, &state); err != nil {
+				log.Printf("Bad game state: %v", err)
+				continue
+			}
+			if !state.IsYourTurn || state.GameStatus != "in_progress" {
+				continue
+			}
+			board, err := replayMoves(state.UGNMoves)
+			if err != nil {
+				log.Printf("Failed to rebuild board: %v", err)
+				continue
+			}
+			move, ok := player.ChooseMove(board)
+			if !ok {
+				continue
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(move.ToString())); err != nil {
+				log.Println("Error sending move:", err)
+				return
+			}
+		case game.MessageTypeGameOver:
+			var over game.GameOverPayload
+			json.Unmarshal(msg.Payload, &over)
+			log.Printf("Game over: %s", over.Message)
+			return
+		case game.MessageTypeError, game.MessageTypeInfo:
+			var info game.InfoPayload
+			json.Unmarshal(msg.Payload, &info)
+			log.Printf("Server: %s", info.Message)
+		}
+	}
+}
+
+func replayMoves(moves []string) (*game.UltimateBoard, error) {
+	board := game.NewUltimateBoard()
+	for _, moveStr := range moves {
+		move, err := ugn.ParseMove(moveStr)
+		if err != nil {
+			return nil, err
+		}
+		if err := board.MakeMove(move.BoardIndex, move.Position); err != nil {
+			return nil, err
+		}
+	}
+	return board, nil
+}
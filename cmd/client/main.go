@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"os/signal"
 	"strings"
@@ -17,9 +18,9 @@ func main() {
 		playerName = os.Args[1]
 	}
 
-	serverURL := "ws://localhost:8080/ws"
-	if playerName != "" {
-		serverURL += "?name=" + playerName
+	serverURL := "ws://localhost:8080/ws?name=" + url.QueryEscape(playerName)
+	if len(os.Args) > 2 && os.Args[2] == "puzzle" {
+		serverURL += "&mode=puzzle"
 	}
 
 	fmt.Println("Connecting to Ultimate Tic-Tac-Toe Matchmaking Server")
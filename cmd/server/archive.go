@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/archive"
+	"github.com/eshahhh/ultimatetictactoe/internal/explorer"
+)
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Failed to write JSON response: %v", err)
+	}
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+func (gs *GameServer) handleGameSearch(w http.ResponseWriter, r *http.Request) {
+	if gs.archive == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, "game archive is not available")
+		return
+	}
+	q, err := archive.ParseQuery(r.URL.Query())
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, err := gs.archive.Refresh(); err != nil {
+		log.Printf("Failed to update game archive index: %v", err)
+	}
+
+	entries := gs.archive.Search(q)
+	results := make([]archive.Entry, 0, len(entries))
+	for _, e := range entries {
+		result := *e
+		result.Positions = nil
+		results = append(results, result)
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+func (gs *GameServer) handleExplorer(w http.ResponseWriter, r *http.Request) {
+	if gs.explorer == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, "game archive is not available")
+		return
+	}
+	prefix, filter, err := explorer.ParseRequest(r.URL.Query())
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, err := gs.archive.Refresh(); err != nil {
+		log.Printf("Failed to update game archive index: %v", err)
+	}
+
+	report, err := gs.explorer.Explore(prefix, filter)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/archive"
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+	"github.com/eshahhh/ultimatetictactoe/internal/render"
+	"github.com/eshahhh/ultimatetictactoe/internal/ugn"
+)
+
+const maxDiagramSize = 2000
+
+// gameMoves finds a game's moves, preferring a live session over the archive.
+func (gs *GameServer) gameMoves(id string) ([]game.Move, error) {
+	if session := gs.gameManager.GetSession(id); session != nil {
+		_, moves := session.Snapshot()
+		return moves, nil
+	}
+	if gs.archive == nil {
+		return nil, fmt.Errorf("game %s not found", id)
+	}
+	if _, err := gs.archive.Refresh(); err != nil {
+		log.Printf("Failed to update game archive index: %v", err)
+	}
+	entries := gs.archive.Search(archive.Query{GameID: id, Limit: 1})
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("game %s not found", id)
+	}
+	g, err := ugn.ParseUGNFile(gs.archive.Path(entries[0]))
+	if err != nil {
+		return nil, err
+	}
+	moves := make([]game.Move, len(g.Moves))
+	for i, m := range g.Moves {
+		moves[i] = game.Move{BoardIndex: m.BoardIndex, Position: m.Position}
+	}
+	return moves, nil
+}
+
+// handleBoardDiagram serves /games/{id}/board.svg and board.png, optionally
+// at ?ply=N and ?size=N pixels.
+func (gs *GameServer) handleBoardDiagram(w http.ResponseWriter, r *http.Request) {
+	moves, err := gs.gameMoves(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	ply := len(moves)
+	if s := r.URL.Query().Get("ply"); s != "" {
+		ply, err = strconv.Atoi(s)
+		if err != nil || ply < 0 || ply > len(moves) {
+			http.Error(w, fmt.Sprintf("ply must be between 0 and %d", len(moves)), http.StatusBadRequest)
+			return
+		}
+	}
+	opts := render.Options{}
+	if s := r.URL.Query().Get("size"); s != "" {
+		opts.Size, err = strconv.Atoi(s)
+		if err != nil || opts.Size < 1 || opts.Size > maxDiagramSize {
+			http.Error(w, fmt.Sprintf("size must be between 1 and %d", maxDiagramSize), http.StatusBadRequest)
+			return
+		}
+	}
+
+	board := game.NewUltimateBoard()
+	for i := 0; i < ply; i++ {
+		if err := board.MakeMove(moves[i].BoardIndex, moves[i].Position); err != nil {
+			http.Error(w, fmt.Sprintf("move %d: %v", i+1, err), http.StatusInternalServerError)
+			return
+		}
+	}
+	if ply > 0 {
+		opts.LastMove = &moves[ply-1]
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if strings.HasSuffix(r.URL.Path, ".png") {
+		w.Header().Set("Content-Type", "image/png")
+		err = render.PNG(w, board, opts)
+	} else {
+		w.Header().Set("Content-Type", "image/svg+xml")
+		err = render.SVG(w, board, opts)
+	}
+	if err != nil {
+		log.Printf("Failed to render board diagram: %v", err)
+	}
+}
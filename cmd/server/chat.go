@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/chat"
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+	"github.com/eshahhh/ultimatetictactoe/internal/ugn"
+)
+
+// loadChatFilter reads the banned word list. Without one chat goes
+// unfiltered.
+func (gs *GameServer) loadChatFilter(path string) {
+	filter, err := chat.LoadFilter(path)
+	if err != nil {
+		log.Printf("Chat filter disabled: %v", err)
+		return
+	}
+	gs.chatFilter = filter
+	log.Printf("Loaded %d filtered words from %s", filter.Len(), path)
+}
+
+// openChatLog starts the chat file kept next to the session's UGN record.
+func (gs *GameServer) openChatLog(session *game.GameSession, logger *ugn.GameLogger) {
+	path := logger.RecordPath()
+	if path == "" {
+		return
+	}
+	gs.chatLogsMutex.Lock()
+	defer gs.chatLogsMutex.Unlock()
+	gs.chatLogs[session.ID] = chat.NewLog(chat.LogPath(path))
+}
+
+func (gs *GameServer) chatLog(session *game.GameSession) *chat.Log {
+	gs.chatLogsMutex.Lock()
+	defer gs.chatLogsMutex.Unlock()
+	return gs.chatLogs[session.ID]
+}
+
+// forgetChatLog drops the session's chat log once nobody can chat in the
+// game any more: both players have left or moved on to a rematch.
+func (gs *GameServer) forgetChatLog(session *game.GameSession) {
+	gs.chatLogsMutex.Lock()
+	defer gs.chatLogsMutex.Unlock()
+	delete(gs.chatLogs, session.ID)
+}
+
+// sendChat passes a player's message to their opponent unless the opponent
+// has muted them, echoes it back, and shares it with the spectators when
+// asked to.
+func (gs *GameServer) sendChat(c *client, request *game.ChatRequestPayload) error {
+	text, err := chat.Validate(request.Text)
+	if err != nil {
+		return fmt.Errorf("Cannot send chat: %v", err)
+	}
+	now := time.Now()
+	if c.chatLimit == nil {
+		c.chatLimit = chat.NewLimiter(chat.RateLimit, chat.RateWindow)
+	}
+	if !c.chatLimit.Allow(now) {
+		return fmt.Errorf("Cannot send chat: at most %d messages per %s", chat.RateLimit, chat.RateWindow)
+	}
+	text = gs.chatFilter.Clean(text)
+
+	session, player := c.seat()
+	conn := c.connection()
+	payload := game.ChatPayload{
+		From:       c.name,
+		Symbol:     player.Symbol.String(),
+		Text:       text,
+		Spectators: request.Spectators,
+		Time:       now.UnixMilli(),
+	}
+	if opponent := session.GetOpponent(player); opponent != nil && !session.HasMuted(opponent) {
+		sendJSONMessage(session.PlayerConn(opponent), game.MessageTypeChat, payload)
+	}
+	sendJSONMessage(conn, game.MessageTypeChat, payload)
+	if request.Spectators {
+		for _, spectator := range session.Spectators() {
+			sendJSONMessage(spectator.Conn, game.MessageTypeChat, payload)
+		}
+	}
+
+	if chatLog := gs.chatLog(session); chatLog != nil {
+		err := chatLog.Append(chat.Message{
+			Time:       now,
+			From:       c.name,
+			Symbol:     payload.Symbol,
+			Text:       text,
+			Spectators: request.Spectators,
+		})
+		if err != nil {
+			log.Printf("Failed to log chat of game %s: %v", session.ID, err)
+		}
+	}
+	return nil
+}
+
+func (gs *GameServer) setMuted(c *client, muted bool) error {
+	session, player := c.seat()
+	conn := c.connection()
+	session.SetMuted(player, muted)
+	infoMsg := "Your opponent's chat is unmuted"
+	if muted {
+		infoMsg = "Your opponent's chat is muted"
+	}
+	sendJSONMessage(conn, game.MessageTypeInfo, game.InfoPayload{Message: infoMsg})
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+)
+
+// clockTick is how often running clocks are checked for flag fall.
+const clockTick = 100 * time.Millisecond
+
+// watchClock ends a timed game when the player to move runs out of time.
+func (gs *GameServer) watchClock(session *game.GameSession) {
+	ticker := time.NewTicker(clockTick)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if session.IsFinished() {
+			return
+		}
+		if loser := session.CheckFlag(); loser != nil {
+			gs.announceFlag(session, loser)
+			return
+		}
+	}
+}
+
+func (gs *GameServer) announceFlag(session *game.GameSession, loser *game.Player) {
+	winnerName := playerName(session, session.Winner)
+	log.Printf("Game %s: %s (%s) lost on time", session.ID, loser.Name, loser.Symbol)
+
+	gameOverPayload := game.GameOverPayload{
+		Winner:     session.Winner.String(),
+		WinnerName: winnerName,
+		Message:    fmt.Sprintf("%s wins on time!", winnerName),
+		Comment:    "time",
+	}
+	broadcastGameState(session)
+	broadcast(session, game.MessageTypeGameOver, gameOverPayload)
+}
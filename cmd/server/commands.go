@@ -0,0 +1,338 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/chat"
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+	"github.com/gorilla/websocket"
+)
+
+// client is a player and the game it is playing, if any. Its connection
+// changes when it reconnects with its resume token, and its game when the
+// opponent accepts a rematch, so both are read through the client's mutex.
+type client struct {
+	id    string
+	name  string
+	room  string // code of the private room the client opened
+	token string
+	quit  bool
+	away  chan struct{} // closed when a disconnected client comes back
+
+	chatLimit *chat.Limiter
+
+	mutex   sync.Mutex
+	conn    *websocket.Conn
+	session *game.GameSession
+	player  *game.Player
+}
+
+// seat returns the game the client is playing and its player in it, or nils
+// while it is still waiting for one.
+func (c *client) seat() (*game.GameSession, *game.Player) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.session, c.player
+}
+
+// sit moves the client to player's seat in session.
+func (c *client) sit(session *game.GameSession, player *game.Player) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.session = session
+	c.player = player
+}
+
+// connection returns the client's current connection, or nil while it is
+// disconnected.
+func (c *client) connection() *websocket.Conn {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.conn
+}
+
+// setConnection replaces the client's connection and returns the old one.
+func (c *client) setConnection(conn *websocket.Conn) *websocket.Conn {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	old := c.conn
+	c.conn = conn
+	return old
+}
+
+// respond acknowledges a request that carried a request ID. Requests
+// without one (plain text commands) only hear back about errors.
+func respond(conn *websocket.Conn, msg *game.ClientMessage, err error) {
+	if msg.RequestID != "" {
+		ack := game.AckPayload{RequestID: msg.RequestID, Type: msg.Type, OK: err == nil}
+		if err != nil {
+			ack.Error = err.Error()
+		}
+		sendJSONMessage(conn, game.MessageTypeAck, ack)
+		return
+	}
+	if err != nil {
+		sendJSONMessage(conn, game.MessageTypeError, game.ErrorPayload{Message: err.Error()})
+	}
+}
+
+const gameHelp = "Commands:\n" +
+	"  A1-I9: Make a move (e.g., A1, B5, I9)\n" +
+	"  R or resign: Resign from the game\n" +
+	"  takeback/undo: Ask to take back your last move\n" +
+	"  rematch: Offer a rematch once the game is over\n" +
+	"  say <text>: Chat with your opponent (shout <text> to include spectators)\n" +
+	"  mute/unmute: Hide or show your opponent's chat\n" +
+	"  board/show: Request board update\n" +
+	"  status: Show game status\n" +
+	"  quit/exit: Leave the game"
+
+// findSession binds the client to the game matchmaking put it in. Games
+// that were followed by a rematch are skipped.
+func (gs *GameServer) findSession(c *client) bool {
+	conn := c.connection()
+	for _, sessionID := range gs.gameManager.GetActiveSessions() {
+		session := gs.gameManager.GetSession(sessionID)
+		if session == nil || session.Latest() != session {
+			continue
+		}
+		if player := session.PlayerByConn(conn); player != nil {
+			c.sit(session, player)
+			return true
+		}
+	}
+	return false
+}
+
+func (gs *GameServer) handleClientMessage(c *client, msg *game.ClientMessage) error {
+	session, player := c.seat()
+	if session == nil && gs.findSession(c) {
+		session, player = c.seat()
+	}
+	conn := c.connection()
+
+	switch msg.Type {
+	case game.ClientHelp:
+		sendJSONMessage(conn, game.MessageTypeInfo, game.InfoPayload{Message: gameHelp})
+		return nil
+	case game.ClientStatus:
+		if session != nil {
+			sendGameStateToPlayer(session, player)
+		} else if c.room != "" {
+			statusMsg := fmt.Sprintf("Waiting for your friend to join room %s", c.room)
+			sendJSONMessage(conn, game.MessageTypeInfo, game.InfoPayload{Message: statusMsg})
+		} else {
+			statusMsg := fmt.Sprintf("Waiting for match... Players in queue: %d", gs.matchmaker.GetTotalQueueSize())
+			sendJSONMessage(conn, game.MessageTypeInfo, game.InfoPayload{Message: statusMsg})
+		}
+		return nil
+	}
+
+	if session == nil {
+		return fmt.Errorf("Still waiting for a match... Type 'status' for queue info")
+	}
+
+	switch msg.Type {
+	case game.ClientBoard:
+		sendGameStateToPlayer(session, player)
+		return nil
+	case game.ClientResign:
+		return gs.resign(c)
+	case game.ClientOfferDraw:
+		return gs.offerDraw(c)
+	case game.ClientAcceptDraw:
+		return gs.acceptDraw(c)
+	case game.ClientDeclineDraw:
+		return gs.declineDraw(c)
+	case game.ClientRematch:
+		return gs.offerRematch(c)
+	case game.ClientAcceptRematch:
+		return gs.acceptRematch(c)
+	case game.ClientDeclineRematch:
+		return gs.declineRematch(c)
+	case game.ClientTakeback:
+		return gs.requestTakeback(c)
+	case game.ClientAcceptTakeback:
+		return gs.acceptTakeback(c)
+	case game.ClientDeclineTakeback:
+		return gs.declineTakeback(c)
+	case game.ClientChat:
+		request, err := msg.Chat()
+		if err != nil {
+			return err
+		}
+		return gs.sendChat(c, request)
+	case game.ClientMute:
+		return gs.setMuted(c, true)
+	case game.ClientUnmute:
+		return gs.setMuted(c, false)
+	case game.ClientMove:
+		move, err := msg.Move()
+		if err != nil {
+			return fmt.Errorf("Invalid move format: %v", err)
+		}
+		return gs.makeMove(c, move)
+	}
+	return fmt.Errorf("Unsupported message type %q", msg.Type)
+}
+
+func (gs *GameServer) resign(c *client) error {
+	session, player := c.seat()
+	if err := session.ResignGame(player); err != nil {
+		return fmt.Errorf("Cannot resign: %v", err)
+	}
+
+	resignMsg := fmt.Sprintf("Player %s (%s) has resigned!", c.name, player.Symbol)
+	broadcast(session, game.MessageTypeInfo, game.InfoPayload{Message: resignMsg})
+	broadcastGameState(session)
+
+	winnerName := playerName(session, session.Winner)
+	gameOverPayload := game.GameOverPayload{
+		Winner:     session.Winner.String(),
+		WinnerName: winnerName,
+		Message:    fmt.Sprintf("%s wins by resignation!", winnerName),
+		Comment:    "resignation",
+	}
+	broadcast(session, game.MessageTypeGameOver, gameOverPayload)
+	return nil
+}
+
+func (gs *GameServer) offerDraw(c *client) error {
+	session, player := c.seat()
+	conn := c.connection()
+	if err := session.OfferDraw(player); err != nil {
+		return fmt.Errorf("Cannot offer draw: %v", err)
+	}
+
+	if opponent := session.GetOpponent(player); opponent != nil {
+		drawOfferMsg := fmt.Sprintf("Player %s has offered a draw. Type ACCEPT_DRAW or DECLINE_DRAW", c.name)
+		sendJSONMessage(session.PlayerConn(opponent), game.MessageTypeDrawOffer, game.DrawOfferPayload{
+			OfferedBy: c.name,
+			Message:   drawOfferMsg,
+		})
+	}
+	sendJSONMessage(conn, game.MessageTypeInfo, game.InfoPayload{Message: "Draw offer sent"})
+	return nil
+}
+
+func (gs *GameServer) acceptDraw(c *client) error {
+	session, player := c.seat()
+	if !session.DrawOfferPending {
+		return fmt.Errorf("No draw offer pending")
+	}
+	if session.DrawOfferedBy == player {
+		return fmt.Errorf("Cannot accept your own draw offer")
+	}
+	if err := session.AcceptDraw(); err != nil {
+		return fmt.Errorf("Error accepting draw: %v", err)
+	}
+
+	broadcast(session, game.MessageTypeInfo, game.InfoPayload{Message: "Draw offer accepted! Game ended in a draw."})
+	broadcastGameState(session)
+
+	gameOverPayload := game.GameOverPayload{
+		Winner:     "Draw",
+		WinnerName: "Draw",
+		Message:    "Game ended in a draw by agreement",
+		Comment:    "agreement",
+	}
+	broadcast(session, game.MessageTypeGameOver, gameOverPayload)
+	return nil
+}
+
+func (gs *GameServer) declineDraw(c *client) error {
+	session, player := c.seat()
+	conn := c.connection()
+	if !session.DrawOfferPending {
+		return fmt.Errorf("No draw offer pending")
+	}
+	if session.DrawOfferedBy == player {
+		return fmt.Errorf("Cannot decline your own draw offer")
+	}
+	if err := session.DeclineDraw(); err != nil {
+		return fmt.Errorf("Error declining draw: %v", err)
+	}
+
+	if opponent := session.GetOpponent(player); opponent != nil {
+		sendJSONMessage(session.PlayerConn(opponent), game.MessageTypeInfo, game.InfoPayload{Message: "Draw offer declined"})
+	}
+	sendJSONMessage(conn, game.MessageTypeInfo, game.InfoPayload{Message: "Draw offer declined"})
+	return nil
+}
+
+func (gs *GameServer) makeMove(c *client, move *game.Move) error {
+	session, player := c.seat()
+	if err := session.MakeMove(player, move); err != nil {
+		if errors.Is(err, game.ErrTimeUp) {
+			gs.announceFlag(session, player)
+		}
+		return fmt.Errorf("Invalid move: %v", err)
+	}
+
+	log.Printf("Move made successfully. Current UGN moves: %v", session.GetUGNMoves())
+
+	movePayload := game.MovePayload{
+		PlayerName:   c.name,
+		PlayerSymbol: player.Symbol.String(),
+		Move:         move.ToString(),
+		BoardIndex:   move.BoardIndex,
+		Position:     move.Position,
+	}
+	broadcast(session, game.MessageTypeMove, movePayload)
+	broadcastGameState(session)
+
+	if session.IsFinished() {
+		winner := "Draw"
+		winnerName := "Draw"
+		if session.Winner != game.Empty {
+			winner = session.Winner.String()
+			winnerName = playerName(session, session.Winner)
+		}
+
+		gameOverPayload := game.GameOverPayload{
+			Winner:     winner,
+			WinnerName: winnerName,
+			Message:    fmt.Sprintf("Game Over - %s!", session.GetGameStatus()),
+			Comment:    "",
+		}
+		broadcast(session, game.MessageTypeGameOver, gameOverPayload)
+	}
+	return nil
+}
+
+// broadcast sends a message to both players and every spectator.
+func broadcast(session *game.GameSession, msgType game.MessageType, payload interface{}) {
+	for _, player := range session.PlayerList() {
+		sendJSONMessage(player.Conn, msgType, payload)
+	}
+	for _, spectator := range session.Spectators() {
+		sendJSONMessage(spectator.Conn, msgType, payload)
+	}
+}
+
+// broadcastGameState sends each player their view of the game and the
+// spectators the neutral one.
+func broadcastGameState(session *game.GameSession) {
+	for _, player := range session.PlayerList() {
+		sendGameStateToPlayer(session, &player)
+	}
+	if spectators := session.Spectators(); len(spectators) > 0 {
+		state := session.GetGameStateForPlayer(nil)
+		for _, spectator := range spectators {
+			sendJSONMessage(spectator.Conn, game.MessageTypeGameState, state)
+		}
+	}
+}
+
+// playerName returns the name of the player with the given symbol.
+func playerName(session *game.GameSession, symbol game.CellState) string {
+	for _, p := range session.PlayerList() {
+		if p.Symbol == symbol {
+			return p.Name
+		}
+	}
+	return ""
+}
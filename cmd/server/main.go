@@ -3,14 +3,23 @@ package main
 import (
 	"crypto/rand"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"math/big"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/eshahhh/ultimatetictactoe/internal/archive"
+	"github.com/eshahhh/ultimatetictactoe/internal/chat"
+	"github.com/eshahhh/ultimatetictactoe/internal/explorer"
 	"github.com/eshahhh/ultimatetictactoe/internal/game"
 	"github.com/eshahhh/ultimatetictactoe/internal/matchmaking"
+	"github.com/eshahhh/ultimatetictactoe/internal/puzzle"
+	"github.com/eshahhh/ultimatetictactoe/internal/rating"
 	"github.com/eshahhh/ultimatetictactoe/internal/ugn"
 	"github.com/gorilla/websocket"
 )
@@ -22,26 +31,108 @@ var upgrader = websocket.Upgrader{
 }
 
 type GameServer struct {
-	gameManager    *game.GameManager
-	matchmaker     *matchmaking.MatchmakingManager
-	gamesDir       string
-	playerSessions map[string]*websocket.Conn
+	gameManager       *game.GameManager
+	matchmaker        *matchmaking.MatchmakingManager
+	archive           *archive.Index
+	explorer          *explorer.Explorer
+	ratings           *rating.Table
+	puzzles           []*puzzle.Puzzle
+	puzzleRatings     *rating.Table
+	gamesDir          string
+	ratingsFile       string
+	puzzlesFile       string
+	puzzleRatingsFile string
+	playerSessions    map[string]*websocket.Conn
+	clients           map[string]*client // by resume token
+	clientsMutex      sync.Mutex
+	reconnectGrace    time.Duration
+	chatFilter        *chat.Filter
+	chatLogs          map[string]*chat.Log // by game ID
+	chatLogsMutex     sync.Mutex
 }
 
 func NewGameServer() *GameServer {
 	gs := &GameServer{
-		gameManager:    game.NewGameManager(),
-		gamesDir:       "games",
-		playerSessions: make(map[string]*websocket.Conn),
+		gameManager:       game.NewGameManager(),
+		gamesDir:          "games",
+		ratingsFile:       "ratings.json",
+		puzzlesFile:       "puzzles.ugn",
+		puzzleRatingsFile: "puzzle_ratings.json",
+		playerSessions:    make(map[string]*websocket.Conn),
+		clients:           make(map[string]*client),
+		chatLogs:          make(map[string]*chat.Log),
+		reconnectGrace:    DefaultReconnectGrace,
 	}
 
+	recovered, err := ugn.RecoverJournals(gs.gamesDir)
+	if err != nil {
+		log.Printf("Some game journals could not be recovered and were set aside as .journal.bad: %v", err)
+	}
+	for _, path := range recovered {
+		log.Printf("Recovered unfinished game into %s", path)
+	}
+
+	gs.archive, err = archive.Open(gs.gamesDir)
+	if err != nil {
+		log.Printf("Failed to open game archive index: %v", err)
+		gs.archive = nil
+	} else if changed, err := gs.archive.Update(); err != nil {
+		log.Printf("Failed to index game archive: %v", err)
+	} else {
+		log.Printf("Game archive indexed: %d games (%d changed)", gs.archive.Len(), changed)
+	}
+
+	gs.ratings, err = rating.Load(gs.ratingsFile)
+	if err != nil {
+		log.Printf("Failed to load ratings: %v", err)
+		gs.ratings = rating.NewTable()
+	}
+	if gs.archive != nil {
+		gs.explorer = explorer.New(gs.archive, gs.ratings)
+	}
+
+	gs.loadPuzzles()
+
 	gs.matchmaker = matchmaking.NewMatchmakingManager(gs.onMatchFound)
 	gs.matchmaker.Start()
 
 	return gs
 }
 
+// connWriters holds a write lock for every open connection. gorilla/websocket
+// allows one writer at a time, and besides its own handler a player's
+// connection is written to by the opponent's and spectators' handlers and by
+// the clock and disconnect countdown goroutines.
+var (
+	connWriters      = make(map[*websocket.Conn]*sync.Mutex)
+	connWritersMutex sync.Mutex
+)
+
+func openConn(conn *websocket.Conn) {
+	connWritersMutex.Lock()
+	defer connWritersMutex.Unlock()
+	connWriters[conn] = new(sync.Mutex)
+}
+
+// closeConn closes conn; later writes to it fail without touching it.
+func closeConn(conn *websocket.Conn) {
+	connWritersMutex.Lock()
+	delete(connWriters, conn)
+	connWritersMutex.Unlock()
+	conn.Close()
+}
+
 func sendJSONMessage(conn *websocket.Conn, msgType game.MessageType, payload interface{}) error {
+	if conn == nil {
+		return fmt.Errorf("player is not connected")
+	}
+	connWritersMutex.Lock()
+	writeLock := connWriters[conn]
+	connWritersMutex.Unlock()
+	if writeLock == nil {
+		return fmt.Errorf("connection is closed")
+	}
+
 	msg := game.WebSocketMessage{
 		Type:    msgType,
 		Payload: payload,
@@ -50,6 +141,8 @@ func sendJSONMessage(conn *websocket.Conn, msgType game.MessageType, payload int
 	if err != nil {
 		return fmt.Errorf("failed to marshal JSON: %v", err)
 	}
+	writeLock.Lock()
+	defer writeLock.Unlock()
 	return conn.WriteMessage(websocket.TextMessage, data)
 }
 
@@ -59,7 +152,7 @@ func sendGameStateToPlayer(session *game.GameSession, player *game.Player) error
 	if len(gameState.UGNMoves) > 0 {
 		log.Printf("UGN moves: %v", gameState.UGNMoves)
 	}
-	return sendJSONMessage(player.Conn, game.MessageTypeGameState, gameState)
+	return sendJSONMessage(session.PlayerConn(player), game.MessageTypeGameState, gameState)
 }
 
 func (gs *GameServer) onMatchFound(match *matchmaking.GameMatch) error {
@@ -75,14 +168,25 @@ func (gs *GameServer) onMatchFound(match *matchmaking.GameMatch) error {
 		player1.Connection, player1.Name,
 		player2.Connection, player2.Name,
 	)
+	gs.gameManager.AddSession(session)
+
+	timeControl, err := game.ParseTimeControl(match.TimeControl)
+	if err != nil {
+		log.Printf("Ignoring time control of game %s: %v", match.GameID, err)
+	}
+	gs.startSession(session, timeControl)
+	return nil
+}
 
+// startSession starts logging a new game, starts its clocks and tells both
+// players.
+func (gs *GameServer) startSession(session *game.GameSession, timeControl game.TimeControl) {
 	sessionLogger := ugn.NewGameLogger(gs.gamesDir)
 	session.SetLogger(sessionLogger)
 
-	gs.gameManager.AddSession(session)
-
+	players := session.PlayerList()
 	var playerXName, playerOName string
-	for _, player := range session.Players {
+	for _, player := range players {
 		if player.Symbol == game.X {
 			playerXName = player.Name
 		} else {
@@ -91,30 +195,49 @@ func (gs *GameServer) onMatchFound(match *matchmaking.GameMatch) error {
 	}
 
 	if session.Logger != nil {
-		err := session.Logger.StartGame(match.GameID, playerXName, playerOName)
+		err := session.Logger.StartGame(session.ID, playerXName, playerOName)
 		if err != nil {
 			log.Printf("Failed to start game logging: %v", err)
 		} else {
-			log.Printf("Game logging started for game %s", match.GameID)
+			log.Printf("Game logging started for game %s", session.ID)
+			gs.openChatLog(session, sessionLogger)
+		}
+		if session.Round > 0 {
+			session.Logger.SetTag("Round", strconv.Itoa(session.Round))
+			session.Logger.SetTag("PreviousGame", session.PreviousGame)
 		}
 	}
 
-	for _, player := range session.Players {
-		err := sendGameStateToPlayer(session, player)
+	if timeControl.Timed() {
+		if err := session.SetTimeControl(timeControl); err != nil {
+			log.Printf("Failed to record time control of game %s: %v", session.ID, err)
+		}
+		go gs.watchClock(session)
+	}
+
+	for _, player := range players {
+		err := sendGameStateToPlayer(session, &player)
 		if err != nil {
 			log.Printf("Failed to send game state to player %s: %v", player.Name, err)
 		}
 
-		welcomeMsg := fmt.Sprintf("Match found! Game ID: %s. You are player %s", match.GameID, player.Symbol)
+		welcomeMsg := fmt.Sprintf("Match found! Game ID: %s. You are player %s", session.ID, player.Symbol)
+		if session.Round > 0 {
+			welcomeMsg = fmt.Sprintf("Rematch! Round %d, game ID: %s. You are player %s", session.Round, session.ID, player.Symbol)
+		}
+		if timeControl.Timed() {
+			welcomeMsg += fmt.Sprintf(". Time control: %s", timeControl)
+		}
+		if session.Rated {
+			welcomeMsg += ". Rated game, no takebacks"
+		}
 		sendJSONMessage(player.Conn, game.MessageTypeInfo, game.InfoPayload{Message: welcomeMsg})
 	}
 
 	log.Printf("Game %s started with players %s (%s) and %s (%s)",
-		match.GameID,
-		session.Players[0].Name, session.Players[0].Symbol,
-		session.Players[1].Name, session.Players[1].Symbol)
-
-	return nil
+		session.ID,
+		players[0].Name, players[0].Symbol,
+		players[1].Name, players[1].Symbol)
 }
 
 func generatePlayerID() string {
@@ -136,340 +259,169 @@ func (gs *GameServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		log.Printf("WebSocket upgrade error: %v", err)
 		return
 	}
-	defer conn.Close()
+	openConn(conn)
+	defer closeConn(conn)
 
 	playerName := r.URL.Query().Get("name")
 	if playerName == "" {
 		playerName = r.RemoteAddr
 	}
 
+	if r.URL.Query().Get("mode") == "puzzle" {
+		gs.handlePuzzleMode(conn, playerName)
+		return
+	}
+	if gameID := r.URL.Query().Get("watch"); gameID != "" {
+		gs.handleSpectator(conn, playerName, gameID)
+		return
+	}
+
+	timeControl, err := game.ParseTimeControl(r.URL.Query().Get("tc"))
+	if err != nil {
+		sendJSONMessage(conn, game.MessageTypeError, game.ErrorPayload{Message: err.Error()})
+		return
+	}
+
+	if token := r.URL.Query().Get("resume"); token != "" {
+		if c := gs.resume(token, conn); c != nil {
+			gs.serveClient(c, conn)
+			return
+		}
+		const resumeFailed = "Could not resume: the game is over or the reconnect window has passed"
+		// A player who came from a private room must not be matched with a
+		// stranger instead.
+		if r.URL.Query().Get("room") != "" {
+			sendJSONMessage(conn, game.MessageTypeError, game.ErrorPayload{Message: resumeFailed})
+			return
+		}
+		sendJSONMessage(conn, game.MessageTypeInfo, game.InfoPayload{Message: resumeFailed})
+	}
+
 	playerID := generatePlayerID()
 
 	gs.playerSessions[playerID] = conn
 	defer delete(gs.playerSessions, playerID)
 
+	c := &client{id: playerID, name: playerName, conn: conn, token: generateToken()}
+	gs.registerClient(c)
+
+	welcomeMsg := fmt.Sprintf("Welcome %s! Finding you a match...", playerName)
+	if r.URL.Query().Get("room") != "" {
+		welcomeMsg = fmt.Sprintf("Welcome %s!", playerName)
+	}
 	sendJSONMessage(conn, game.MessageTypeWelcome, game.WelcomePayload{
-		PlayerID:   playerID,
-		PlayerName: playerName,
-		Message:    fmt.Sprintf("Welcome %s! Finding you a match...", playerName),
+		PlayerID:    playerID,
+		PlayerName:  playerName,
+		Message:     welcomeMsg,
+		ResumeToken: c.token,
 	})
 
 	log.Printf("Player %s (%s) connected from %s", playerName, playerID, r.RemoteAddr)
 
+	if room := r.URL.Query().Get("room"); room != "" {
+		if room == "new" {
+			err = gs.createRoom(c, r.URL.Query())
+		} else {
+			err = gs.joinRoom(c, room)
+		}
+		if err != nil {
+			sendJSONMessage(conn, game.MessageTypeError, game.ErrorPayload{Message: err.Error()})
+			gs.forgetClient(c)
+			return
+		}
+		gs.serveClient(c, conn)
+		return
+	}
+
 	playerRequest := &matchmaking.PlayerRequest{
 		ID:         playerID,
 		Name:       playerName,
 		Connection: conn,
 		Mode:       matchmaking.SimpleMode,
 	}
+	if timeControl.Timed() {
+		playerRequest.TimeControl = timeControl.String()
+	}
 
 	err = gs.matchmaker.AddPlayer(playerRequest)
 	if err != nil {
 		log.Printf("Error adding player to matchmaker: %v", err)
 		sendJSONMessage(conn, game.MessageTypeError, game.ErrorPayload{Message: err.Error()})
+		gs.forgetClient(c)
 		return
 	}
 
 	queueMsg := fmt.Sprintf("You're in the matchmaking queue. Players waiting: %d", gs.matchmaker.GetTotalQueueSize())
 	sendJSONMessage(conn, game.MessageTypeInfo, game.InfoPayload{Message: queueMsg})
 
-	var currentGameID string
-	var currentSession *game.GameSession
-	var currentPlayer *game.Player
+	gs.serveClient(c, conn)
+}
 
+// serveClient reads the client's requests from conn until it closes or the
+// client reconnects elsewhere.
+func (gs *GameServer) serveClient(c *client, conn *websocket.Conn) {
 	for {
 		messageType, message, err := conn.ReadMessage()
 		if err != nil {
-			log.Printf("Error reading message from %s: %v", playerName, err)
+			log.Printf("Error reading message from %s: %v", c.name, err)
 			break
 		}
+		if messageType != websocket.TextMessage {
+			continue
+		}
+		log.Printf("Received message '%s' from %s (%s)", strings.TrimSpace(string(message)), c.name, c.id)
 
-		if messageType == websocket.TextMessage {
-			moveStr := strings.TrimSpace(string(message))
-			log.Printf("Received message '%s' from %s (%s)", moveStr, playerName, playerID)
-
-			if moveStr == "quit" || moveStr == "exit" {
-				sendJSONMessage(conn, game.MessageTypeInfo, game.InfoPayload{Message: "Goodbye!"})
-				break
-			}
-
-			if moveStr == "status" {
-				if currentSession != nil {
-					sendGameStateToPlayer(currentSession, currentPlayer)
-				} else {
-					statusMsg := fmt.Sprintf("Waiting for match... Players in queue: %d", gs.matchmaker.GetTotalQueueSize())
-					sendJSONMessage(conn, game.MessageTypeInfo, game.InfoPayload{Message: statusMsg})
-				}
-				continue
-			}
-
-			if moveStr == "help" || moveStr == "?" {
-				helpMsg := "Commands:\n" +
-					"  A1-I9: Make a move (e.g., A1, B5, I9)\n" +
-					"  R or resign: Resign from the game\n" +
-					"  board/show: Request board update\n" +
-					"  status: Show game status\n" +
-					"  quit/exit: Leave the game"
-				sendJSONMessage(conn, game.MessageTypeInfo, game.InfoPayload{Message: helpMsg})
-				continue
-			}
-
-			if currentSession == nil {
-				sessions := gs.gameManager.GetActiveSessions()
-				for _, sessionID := range sessions {
-					session := gs.gameManager.GetSession(sessionID)
-					if session != nil {
-						for _, player := range session.Players {
-							if player != nil && player.Conn == conn {
-								currentGameID = sessionID
-								currentSession = session
-								currentPlayer = player
-								break
-							}
-						}
-						if currentSession != nil {
-							break
-						}
-					}
-				}
-
-				if currentSession == nil {
-					sendJSONMessage(conn, game.MessageTypeInfo, game.InfoPayload{
-						Message: "Still waiting for a match... Type 'status' for queue info",
-					})
-					continue
-				}
-			}
-
-			if moveStr == "board" || moveStr == "show" {
-				sendGameStateToPlayer(currentSession, currentPlayer)
-				continue
-			}
-
-			if game.IsResignation(moveStr) {
-				err := currentSession.ResignGame(currentPlayer)
-				if err != nil {
-					sendJSONMessage(conn, game.MessageTypeError, game.ErrorPayload{Message: "Cannot resign: " + err.Error()})
-					continue
-				}
-
-				resignMsg := fmt.Sprintf("Player %s (%s) has resigned!", playerName, currentPlayer.Symbol)
-				for _, player := range currentSession.Players {
-					if player != nil {
-						sendJSONMessage(player.Conn, game.MessageTypeInfo, game.InfoPayload{Message: resignMsg})
-						sendGameStateToPlayer(currentSession, player)
-					}
-				}
-
-				var winnerName string
-				if currentSession.Winner == game.X {
-					winnerName = currentSession.Players[0].Name
-					if currentSession.Players[0].Symbol == game.O {
-						winnerName = currentSession.Players[1].Name
-					}
-				} else {
-					winnerName = currentSession.Players[1].Name
-					if currentSession.Players[1].Symbol == game.X {
-						winnerName = currentSession.Players[0].Name
-					}
-				}
-
-				gameOverPayload := game.GameOverPayload{
-					Winner:     currentSession.Winner.String(),
-					WinnerName: winnerName,
-					Message:    fmt.Sprintf("%s wins by resignation!", winnerName),
-					Comment:    "resignation",
-				}
-
-				for _, player := range currentSession.Players {
-					if player != nil {
-						sendJSONMessage(player.Conn, game.MessageTypeGameOver, gameOverPayload)
-					}
-				}
-				continue
-			}
-
-			if moveStr == "DRAW" {
-				err := currentSession.OfferDraw(currentPlayer)
-				if err != nil {
-					sendJSONMessage(conn, game.MessageTypeError, game.ErrorPayload{Message: "Cannot offer draw: " + err.Error()})
-					continue
-				}
-
-				opponent := currentSession.GetOpponent(currentPlayer)
-				if opponent != nil {
-					drawOfferMsg := fmt.Sprintf("Player %s has offered a draw. Type ACCEPT_DRAW or DECLINE_DRAW", playerName)
-					sendJSONMessage(opponent.Conn, game.MessageTypeDrawOffer, game.DrawOfferPayload{
-						OfferedBy: playerName,
-						Message:   drawOfferMsg,
-					})
-				}
-				sendJSONMessage(conn, game.MessageTypeInfo, game.InfoPayload{Message: "Draw offer sent"})
-				continue
-			}
-
-			if moveStr == "ACCEPT_DRAW" {
-				if !currentSession.DrawOfferPending {
-					sendJSONMessage(conn, game.MessageTypeError, game.ErrorPayload{Message: "No draw offer pending"})
-					continue
-				}
-
-				if currentSession.DrawOfferedBy == currentPlayer {
-					sendJSONMessage(conn, game.MessageTypeError, game.ErrorPayload{Message: "Cannot accept your own draw offer"})
-					continue
-				}
-
-				err := currentSession.AcceptDraw()
-				if err != nil {
-					sendJSONMessage(conn, game.MessageTypeError, game.ErrorPayload{Message: "Error accepting draw: " + err.Error()})
-					continue
-				}
-
-				for _, player := range currentSession.Players {
-					if player != nil {
-						sendJSONMessage(player.Conn, game.MessageTypeInfo, game.InfoPayload{Message: "Draw offer accepted! Game ended in a draw."})
-						sendGameStateToPlayer(currentSession, player)
-					}
-				}
-
-				gameOverPayload := game.GameOverPayload{
-					Winner:     "Draw",
-					WinnerName: "Draw",
-					Message:    "Game ended in a draw by agreement",
-					Comment:    "agreement",
-				}
-
-				for _, player := range currentSession.Players {
-					if player != nil {
-						sendJSONMessage(player.Conn, game.MessageTypeGameOver, gameOverPayload)
-					}
-				}
-				continue
-			}
-
-			if moveStr == "DECLINE_DRAW" {
-				if !currentSession.DrawOfferPending {
-					sendJSONMessage(conn, game.MessageTypeError, game.ErrorPayload{Message: "No draw offer pending"})
-					continue
-				}
-
-				if currentSession.DrawOfferedBy == currentPlayer {
-					sendJSONMessage(conn, game.MessageTypeError, game.ErrorPayload{Message: "Cannot decline your own draw offer"})
-					continue
-				}
-
-				err := currentSession.DeclineDraw()
-				if err != nil {
-					sendJSONMessage(conn, game.MessageTypeError, game.ErrorPayload{Message: "Error declining draw: " + err.Error()})
-					continue
-				}
-
-				opponent := currentSession.GetOpponent(currentPlayer)
-				if opponent != nil {
-					sendJSONMessage(opponent.Conn, game.MessageTypeInfo, game.InfoPayload{Message: "Draw offer declined"})
-				}
-				sendJSONMessage(conn, game.MessageTypeInfo, game.InfoPayload{Message: "Draw offer declined"})
-				continue
-			}
-
-			move, err := game.ParseMove(moveStr)
-			if err != nil {
-				sendJSONMessage(conn, game.MessageTypeError, game.ErrorPayload{Message: "Invalid move format: " + err.Error()})
-				continue
-			}
-
-			err = currentSession.MakeMove(currentPlayer, move)
-			if err != nil {
-				sendJSONMessage(conn, game.MessageTypeError, game.ErrorPayload{Message: "Invalid move: " + err.Error()})
-				continue
-			}
-
-			log.Printf("Move made successfully. Current UGN moves: %v", currentSession.GetUGNMoves())
-
-			movePayload := game.MovePayload{
-				PlayerName:   playerName,
-				PlayerSymbol: currentPlayer.Symbol.String(),
-				Move:         moveStr,
-				BoardIndex:   move.BoardIndex,
-				Position:     move.Position,
-			}
-
-			for _, player := range currentSession.Players {
-				if player != nil {
-					sendJSONMessage(player.Conn, game.MessageTypeMove, movePayload)
-					sendGameStateToPlayer(currentSession, player)
-				}
-			}
-
-			if currentSession.Finished {
-				var winnerName string
-				var winner string
-
-				switch currentSession.Winner {
-				case game.X:
-					winner = "X"
-					for _, p := range currentSession.Players {
-						if p != nil && p.Symbol == game.X {
-							winnerName = p.Name
-							break
-						}
-					}
-				case game.O:
-					winner = "O"
-					for _, p := range currentSession.Players {
-						if p != nil && p.Symbol == game.O {
-							winnerName = p.Name
-							break
-						}
-					}
-				default:
-					winner = "Draw"
-					winnerName = "Draw"
-				}
-
-				gameOverPayload := game.GameOverPayload{
-					Winner:     winner,
-					WinnerName: winnerName,
-					Message:    fmt.Sprintf("Game Over - %s!", currentSession.GetGameStatus()),
-					Comment:    "",
-				}
-
-				for _, player := range currentSession.Players {
-					if player != nil {
-						sendJSONMessage(player.Conn, game.MessageTypeGameOver, gameOverPayload)
-					}
-				}
+		msg, err := game.ParseClientMessage(message)
+		if err != nil {
+			if msg != nil {
+				respond(conn, msg, err)
+			} else {
+				sendJSONMessage(conn, game.MessageTypeError, game.ErrorPayload{Message: err.Error()})
 			}
+			continue
 		}
-	}
-
-	gs.matchmaker.RemovePlayer(playerID)
-
-	if currentSession != nil && currentPlayer != nil {
-		currentSession.RemovePlayer(conn)
-		log.Printf("Player %s (%s) disconnected from game %s", playerName, playerID, currentGameID)
-
-		if opponent := currentSession.GetOpponent(currentPlayer); opponent != nil {
-			sendJSONMessage(opponent.Conn, game.MessageTypeInfo, game.InfoPayload{
-				Message: fmt.Sprintf("Player %s has disconnected", playerName),
-			})
+		if msg.Type == game.ClientQuit {
+			sendJSONMessage(conn, game.MessageTypeInfo, game.InfoPayload{Message: "Goodbye!"})
+			respond(conn, msg, nil)
+			c.quit = true
+			break
 		}
-	} else {
-		log.Printf("Player %s (%s) disconnected while in matchmaking queue", playerName, playerID)
+		respond(conn, msg, gs.handleClientMessage(c, msg))
 	}
+
+	gs.disconnected(c, conn)
 }
 
 func main() {
+	disconnectTimeout := flag.Duration("disconnect-timeout", DefaultReconnectGrace, "time a disconnected player has to reconnect before forfeiting")
+	chatFilter := flag.String("chat-filter", "chat_filter.txt", "file of words to mask in chat, one per line")
+	flag.Parse()
+
 	gameServer := NewGameServer()
+	gameServer.reconnectGrace = *disconnectTimeout
+	gameServer.loadChatFilter(*chatFilter)
 
 	defer gameServer.matchmaker.Stop()
 
 	http.HandleFunc("/ws", gameServer.handleWebSocket)
+	http.HandleFunc("/games/search", gameServer.handleGameSearch)
+	http.HandleFunc("GET /games/live", gameServer.handleLiveGames)
+	http.HandleFunc("/explorer", gameServer.handleExplorer)
+	http.HandleFunc("GET /games/{id}/board.svg", gameServer.handleBoardDiagram)
+	http.HandleFunc("GET /games/{id}/board.png", gameServer.handleBoardDiagram)
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintf(w, `Ultimate Tic-Tac-Toe WebSocket Server with Matchmaking!
 
 Connect to: ws://localhost:39171/ws
 Optional query parameter: ?name=YourName
+Puzzle mode: ?mode=puzzle (tactics from puzzles.ugn, rated per player)
+Private room: ?room=new (optional tc, color=x|o|random, variant=standard, rated=1)
+  creates a room and replies with its code; a friend joins with ?room=CODE
+Spectate: ?watch=GAMEID (read-only; see GET /games/live for games to watch)
+Time control: ?tc=300+2 (300s each plus 2s per move; 300d2 for a 2s delay)
+  Players are only matched with others asking for the same control.
+Reconnect: ?resume=TOKEN (resume_token from the welcome message, valid for
+  %s after a dropped connection; after that the absent player forfeits)
 
 How it works:
 1. Connect to the server
@@ -481,6 +433,9 @@ Game Commands:
 - A1-I9: Make a move (e.g., A1, B5, I9)
 - R or resign: Resign from the game  
 - board/show: Display the current board
+- takeback/undo: Ask to take back your last move (twice per game, casual games only)
+- say <text>: Chat with your opponent (shout <text> to include spectators)
+- mute/unmute: Hide or show your opponent's chat
 - status: Show game/queue status
 - help: Show this help message
 - quit/exit: Leave the game
@@ -490,7 +445,19 @@ Features:
 - Multiple simultaneous games
 - Random player assignment (X/O)
 - UGN game logging
-- Resignation support`)
+- Resignation support
+
+Live games:
+- GET /games/live (unfinished games with players, move count and spectators)
+
+Archive:
+- GET /games/search?player=alice&as=O&outcome=loss
+- GET /games/search?moves=E5,E1 (games reaching this position)
+  Other parameters: result, game_id, opening, since, until, limit
+- GET /games/{id}/board.svg (or board.png) for a live or archived game
+  Optional parameters: ply, size
+- GET /explorer?moves=E5,E1 (next moves played from a position, with X/O/draw %%)
+  Optional filters: min_rating, max_rating, since, until`, gameServer.reconnectGrace)
 	})
 
 	log.Println("Ultimate Tic-Tac-Toe Server with Matchmaking starting on :39171")
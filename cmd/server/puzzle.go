@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+	"github.com/eshahhh/ultimatetictactoe/internal/puzzle"
+	"github.com/eshahhh/ultimatetictactoe/internal/rating"
+	"github.com/gorilla/websocket"
+)
+
+func (gs *GameServer) loadPuzzles() {
+	puzzles, err := puzzle.Load(gs.puzzlesFile)
+	if err != nil {
+		log.Printf("Puzzle mode disabled: %v", err)
+	} else {
+		gs.puzzles = puzzles
+		log.Printf("Loaded %d puzzles from %s", len(puzzles), gs.puzzlesFile)
+	}
+
+	gs.puzzleRatings, err = rating.Load(gs.puzzleRatingsFile)
+	if err != nil {
+		log.Printf("Failed to load puzzle ratings: %v", err)
+		gs.puzzleRatings = rating.NewTable()
+	}
+}
+
+// startPuzzle picks the unseen puzzle closest to the player's puzzle rating
+// and sends it. Once every puzzle has been seen the cycle starts again.
+func (gs *GameServer) startPuzzle(conn *websocket.Conn, playerName string, seen map[string]bool) *game.PuzzleSession {
+	playerRating := gs.puzzleRatings.Get(playerName)
+	p := puzzle.Closest(gs.puzzles, playerRating, seen)
+	if p == nil {
+		clear(seen)
+		p = puzzle.Closest(gs.puzzles, playerRating, seen)
+	}
+	seen[p.ID] = true
+
+	session, err := game.NewPuzzleSession(generatePlayerID(), p.ID, p.Difficulty, p.Setup, p.Solution, conn, playerName)
+	if err != nil {
+		sendJSONMessage(conn, game.MessageTypeError, game.ErrorPayload{Message: err.Error()})
+		return nil
+	}
+
+	state := session.GetGameState()
+	sendJSONMessage(conn, game.MessageTypeGameState, state)
+	sendJSONMessage(conn, game.MessageTypePuzzle, game.PuzzlePayload{
+		PuzzleID:    p.ID,
+		Difficulty:  p.Difficulty,
+		YourSymbol:  state.YourSymbol,
+		MovesToFind: session.MovesToFind(),
+		Rating:      playerRating,
+		Message: fmt.Sprintf("Puzzle %s (difficulty %d): %s to play and win in %d",
+			p.ID, p.Difficulty, state.YourSymbol, session.MovesToFind()),
+	})
+	return session
+}
+
+func (gs *GameServer) finishPuzzle(session *game.PuzzleSession) {
+	playerName := session.Player.Name
+	before := gs.puzzleRatings.Get(playerName)
+	score := 0.0
+	if session.Solved {
+		score = 1
+	}
+	after := rating.Update(before, session.Difficulty, score)
+	gs.puzzleRatings.Set(playerName, after)
+	if err := gs.puzzleRatings.Save(gs.puzzleRatingsFile); err != nil {
+		log.Printf("Failed to save puzzle ratings: %v", err)
+	}
+
+	outcome, message := "solved", "Puzzle solved!"
+	if !session.Solved {
+		outcome, message = "failed", "Wrong move. Solution: "+strings.Join(session.SolutionStrings(), " ")+"."
+	}
+	message += fmt.Sprintf(" Puzzle rating %d (%+d). Type 'next' for another puzzle.", after, after-before)
+	sendJSONMessage(session.Player.Conn, game.MessageTypePuzzleResult, game.PuzzleResultPayload{
+		PuzzleID:     session.PuzzleID,
+		Solved:       session.Solved,
+		Solution:     session.SolutionStrings(),
+		Rating:       after,
+		RatingChange: after - before,
+		Message:      message,
+	})
+	log.Printf("Player %s %s puzzle %s, rating %d -> %d", playerName, outcome, session.PuzzleID, before, after)
+}
+
+func (gs *GameServer) handlePuzzleMode(conn *websocket.Conn, playerName string) {
+	if len(gs.puzzles) == 0 {
+		sendJSONMessage(conn, game.MessageTypeError, game.ErrorPayload{Message: "Puzzle mode is not available: no puzzles loaded"})
+		return
+	}
+
+	sendJSONMessage(conn, game.MessageTypeWelcome, game.WelcomePayload{
+		PlayerName: playerName,
+		Message:    fmt.Sprintf("Welcome %s! Find the winning moves.", playerName),
+	})
+	log.Printf("Player %s started puzzle mode", playerName)
+
+	seen := make(map[string]bool)
+	session := gs.startPuzzle(conn, playerName, seen)
+
+	for {
+		messageType, message, err := conn.ReadMessage()
+		if err != nil {
+			log.Printf("Error reading message from %s: %v", playerName, err)
+			return
+		}
+		if messageType != websocket.TextMessage {
+			continue
+		}
+
+		msg, err := game.ParseClientMessage(message)
+		if err != nil {
+			if msg != nil {
+				respond(conn, msg, err)
+			} else {
+				sendJSONMessage(conn, game.MessageTypeError, game.ErrorPayload{Message: err.Error()})
+			}
+			continue
+		}
+		if msg.Type == game.ClientQuit {
+			sendJSONMessage(conn, game.MessageTypeInfo, game.InfoPayload{Message: "Goodbye!"})
+			respond(conn, msg, nil)
+			return
+		}
+		if msg.Type == game.ClientNextPuzzle {
+			session = gs.startPuzzle(conn, playerName, seen)
+		} else {
+			err = gs.handlePuzzleMessage(session, msg)
+		}
+		respond(conn, msg, err)
+	}
+}
+
+const puzzleHelp = "Puzzle commands:\n" +
+	"  A1-I9: Make a move (e.g., A1, B5, I9)\n" +
+	"  R or resign: Give up and see the solution\n" +
+	"  next/skip: Move on to another puzzle\n" +
+	"  board/show/status: Request board update\n" +
+	"  quit/exit: Leave puzzle mode"
+
+func (gs *GameServer) handlePuzzleMessage(session *game.PuzzleSession, msg *game.ClientMessage) error {
+	if msg.Type == game.ClientHelp {
+		sendJSONMessage(session.Player.Conn, game.MessageTypeInfo, game.InfoPayload{Message: puzzleHelp})
+		return nil
+	}
+	if session == nil {
+		return fmt.Errorf("No puzzle in progress. Type 'next' for another puzzle")
+	}
+	conn := session.Player.Conn
+
+	switch msg.Type {
+	case game.ClientBoard, game.ClientStatus:
+		sendJSONMessage(conn, game.MessageTypeGameState, session.GetGameState())
+		return nil
+	case game.ClientResign:
+		if err := session.GiveUp(); err != nil {
+			return err
+		}
+		sendJSONMessage(conn, game.MessageTypeGameState, session.GetGameState())
+		gs.finishPuzzle(session)
+		return nil
+	case game.ClientMove:
+	default:
+		return fmt.Errorf("%s is not available in puzzle mode", msg.Type)
+	}
+
+	if session.Finished {
+		return fmt.Errorf("Puzzle is finished. Type 'next' for another puzzle")
+	}
+	move, err := msg.Move()
+	if err != nil {
+		return fmt.Errorf("Invalid move format: %v", err)
+	}
+	symbol := session.Player.Symbol
+	reply, err := session.MakeMove(move)
+	if err != nil {
+		return fmt.Errorf("Invalid move: %v", err)
+	}
+
+	sendJSONMessage(conn, game.MessageTypeMove, game.MovePayload{
+		PlayerName:   session.Player.Name,
+		PlayerSymbol: symbol.String(),
+		Move:         move.ToString(),
+		BoardIndex:   move.BoardIndex,
+		Position:     move.Position,
+	})
+	if reply != nil {
+		replySymbol := game.X
+		if symbol == game.X {
+			replySymbol = game.O
+		}
+		sendJSONMessage(conn, game.MessageTypeMove, game.MovePayload{
+			PlayerName:   "Puzzle",
+			PlayerSymbol: replySymbol.String(),
+			Move:         reply.ToString(),
+			BoardIndex:   reply.BoardIndex,
+			Position:     reply.Position,
+		})
+	}
+	sendJSONMessage(conn, game.MessageTypeGameState, session.GetGameState())
+	if session.Finished {
+		gs.finishPuzzle(session)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+)
+
+func (gs *GameServer) offerRematch(c *client) error {
+	session, player := c.seat()
+	conn := c.connection()
+	if err := session.OfferRematch(player); err != nil {
+		return fmt.Errorf("Cannot offer rematch: %v", err)
+	}
+
+	if opponent := session.GetOpponent(player); opponent != nil {
+		sendJSONMessage(session.PlayerConn(opponent), game.MessageTypeRematchOffer, game.RematchOfferPayload{
+			OfferedBy: c.name,
+			Message:   fmt.Sprintf("Player %s wants a rematch. Type ACCEPT_REMATCH or DECLINE_REMATCH", c.name),
+		})
+	}
+	sendJSONMessage(conn, game.MessageTypeInfo, game.InfoPayload{Message: "Rematch offer sent"})
+	return nil
+}
+
+func (gs *GameServer) declineRematch(c *client) error {
+	session, player := c.seat()
+	conn := c.connection()
+	if err := session.DeclineRematch(player); err != nil {
+		return fmt.Errorf("Cannot decline rematch: %v", err)
+	}
+
+	if opponent := session.GetOpponent(player); opponent != nil {
+		sendJSONMessage(session.PlayerConn(opponent), game.MessageTypeInfo, game.InfoPayload{Message: "Rematch offer declined"})
+	}
+	sendJSONMessage(conn, game.MessageTypeInfo, game.InfoPayload{Message: "Rematch offer declined"})
+	return nil
+}
+
+// acceptRematch starts the next game of the match with the symbols swapped
+// and moves both players, and any spectators, over to it.
+func (gs *GameServer) acceptRematch(c *client) error {
+	previous, player := c.seat()
+	next, err := gs.gameManager.StartRematch(previous, player)
+	if err != nil {
+		return fmt.Errorf("Cannot accept rematch: %v", err)
+	}
+	log.Printf("Rematch of game %s accepted: round %d is game %s", previous.ID, next.Round, next.ID)
+
+	gs.clientsMutex.Lock()
+	for _, cl := range gs.clients {
+		if session, player := cl.seat(); session == previous {
+			cl.sit(next, previous.Counterpart(player, next))
+		}
+	}
+	gs.clientsMutex.Unlock()
+	gs.forgetChatLog(previous)
+
+	gs.startSession(next, previous.GetTimeControl())
+
+	if spectators := next.Spectators(); len(spectators) > 0 {
+		state := next.GetGameStateForPlayer(nil)
+		for _, spectator := range spectators {
+			sendJSONMessage(spectator.Conn, game.MessageTypeInfo, game.InfoPayload{
+				Message: fmt.Sprintf("The players started a rematch, now watching game %s", next.ID),
+			})
+			sendJSONMessage(spectator.Conn, game.MessageTypeGameState, state)
+		}
+	}
+
+	score := next.Score()
+	players := next.PlayerList()
+	scoreMsg := fmt.Sprintf("Match score: %s %g - %s %g", players[0].Name, score[0], players[1].Name, score[1])
+	broadcast(next, game.MessageTypeInfo, game.InfoPayload{Message: scoreMsg})
+	return nil
+}
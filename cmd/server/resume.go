@@ -0,0 +1,238 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+	"github.com/gorilla/websocket"
+)
+
+// DefaultReconnectGrace is how long a disconnected player's seat is kept
+// before they forfeit the game.
+const DefaultReconnectGrace = 60 * time.Second
+
+func generateToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (gs *GameServer) registerClient(c *client) {
+	gs.clientsMutex.Lock()
+	defer gs.clientsMutex.Unlock()
+	gs.clients[c.token] = c
+}
+
+func (gs *GameServer) forgetClient(c *client) {
+	gs.clientsMutex.Lock()
+	defer gs.clientsMutex.Unlock()
+	delete(gs.clients, c.token)
+}
+
+// resume rebinds the client holding token to conn and sends it the full game
+// state. It returns nil if there is no unfinished game to go back to.
+func (gs *GameServer) resume(token string, conn *websocket.Conn) *client {
+	gs.clientsMutex.Lock()
+	c := gs.clients[token]
+	if c == nil {
+		gs.clientsMutex.Unlock()
+		return nil
+	}
+	session, player := c.seat()
+	if session == nil && c.connection() != nil && gs.findSession(c) {
+		session, player = c.seat()
+	}
+	if session == nil || session.IsFinished() {
+		gs.clientsMutex.Unlock()
+		return nil
+	}
+	if c.away != nil {
+		close(c.away)
+		c.away = nil
+	}
+	old := c.setConnection(conn)
+	gs.clientsMutex.Unlock()
+
+	// A connection that has not noticed it dropped yet is closed here, which
+	// ends its read loop.
+	if old != nil {
+		closeConn(old)
+	}
+	if err := session.ReconnectPlayer(player, conn); err != nil {
+		log.Printf("Failed to resume %s in game %s: %v", c.name, session.ID, err)
+		gs.forgetClient(c)
+		return nil
+	}
+
+	sendJSONMessage(conn, game.MessageTypeWelcome, game.WelcomePayload{
+		PlayerID:    c.id,
+		PlayerName:  c.name,
+		Message:     fmt.Sprintf("Welcome back %s!", c.name),
+		ResumeToken: c.token,
+	})
+	sendJSONMessage(conn, game.MessageTypeInfo, game.InfoPayload{
+		Message: fmt.Sprintf("Reconnected to game %s. You are player %s", session.ID, player.Symbol),
+	})
+	sendGameStateToPlayer(session, player)
+
+	if opponent := session.GetOpponent(player); opponent != nil {
+		sendJSONMessage(session.PlayerConn(opponent), game.MessageTypeInfo, game.InfoPayload{
+			Message: fmt.Sprintf("Player %s has reconnected", c.name),
+		})
+	}
+	log.Printf("Player %s (%s) reconnected to game %s", c.name, c.id, session.ID)
+	return c
+}
+
+// disconnected handles the end of one of the client's connections. A player
+// who drops out of an unfinished game keeps their seat for the reconnect
+// grace period and forfeits if they do not come back; everyone else is
+// removed straight away.
+func (gs *GameServer) disconnected(c *client, conn *websocket.Conn) {
+	gs.clientsMutex.Lock()
+	if c.connection() != conn {
+		// Replaced by a resumed connection.
+		gs.clientsMutex.Unlock()
+		return
+	}
+	gs.matchmaker.RemovePlayer(c.id)
+	session, player := c.seat()
+	if session == nil && gs.findSession(c) {
+		session, player = c.seat()
+	}
+	c.setConnection(nil)
+
+	if session == nil || player == nil {
+		delete(gs.clients, c.token)
+		gs.clientsMutex.Unlock()
+		if c.room != "" {
+			gs.gameManager.CloseRoom(c.room)
+			log.Printf("Player %s (%s) disconnected, closing room %s", c.name, c.id, c.room)
+			return
+		}
+		log.Printf("Player %s (%s) disconnected while in matchmaking queue", c.name, c.id)
+		return
+	}
+	if finished := session.IsFinished(); c.quit || finished {
+		delete(gs.clients, c.token)
+		gs.clientsMutex.Unlock()
+		if !finished {
+			gs.abandon(c)
+		}
+		gs.leaveGame(c)
+		return
+	}
+
+	session.DisconnectPlayer(player)
+	c.away = make(chan struct{})
+	go gs.countdown(c, c.away)
+	gs.clientsMutex.Unlock()
+
+	log.Printf("Player %s (%s) dropped from game %s, holding seat for %s", c.name, c.id, session.ID, gs.reconnectGrace)
+	if opponent := session.GetOpponent(player); opponent != nil {
+		sendJSONMessage(session.PlayerConn(opponent), game.MessageTypeInfo, game.InfoPayload{
+			Message: fmt.Sprintf("Player %s has lost connection. Waiting %s for them to reconnect...", c.name, gs.reconnectGrace),
+		})
+	}
+}
+
+// countdown keeps the opponent of a disconnected client informed and ends
+// the game by abandonment when the grace period runs out.
+func (gs *GameServer) countdown(c *client, back <-chan struct{}) {
+	deadline := time.Now().Add(gs.reconnectGrace)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-back:
+			return
+		case <-ticker.C:
+		}
+
+		left := int(time.Until(deadline).Round(time.Second) / time.Second)
+		if left <= 0 {
+			gs.graceExpired(c)
+			return
+		}
+		if left%10 != 0 && left > 5 {
+			continue
+		}
+		session, player := c.seat()
+		if opponent := session.GetOpponent(player); opponent != nil {
+			sendJSONMessage(session.PlayerConn(opponent), game.MessageTypeDisconnectCountdown, game.DisconnectCountdownPayload{
+				PlayerName:  c.name,
+				SecondsLeft: left,
+				Message:     fmt.Sprintf("%s has %s to reconnect", c.name, time.Duration(left)*time.Second),
+			})
+		}
+	}
+}
+
+func (gs *GameServer) graceExpired(c *client) {
+	gs.clientsMutex.Lock()
+	if c.connection() != nil {
+		gs.clientsMutex.Unlock()
+		return
+	}
+	c.away = nil
+	delete(gs.clients, c.token)
+	gs.clientsMutex.Unlock()
+
+	if session, _ := c.seat(); !session.IsFinished() {
+		gs.abandon(c)
+	}
+	gs.leaveGame(c)
+}
+
+// abandon ends the game of a client who did not come back in time. Nothing
+// is announced if the game had already ended some other way.
+func (gs *GameServer) abandon(c *client) {
+	session, player := c.seat()
+	aborted, err := session.AbandonGame(player)
+	if err != nil {
+		log.Printf("Failed to end abandoned game %s: %v", session.ID, err)
+		return
+	}
+
+	gameOverPayload := game.GameOverPayload{
+		Winner:     "Aborted",
+		WinnerName: "Aborted",
+		Message:    fmt.Sprintf("Game aborted: %s left before it got going", c.name),
+		Comment:    "aborted",
+	}
+	if !aborted {
+		winnerName := playerName(session, session.Winner)
+		gameOverPayload = game.GameOverPayload{
+			Winner:     session.Winner.String(),
+			WinnerName: winnerName,
+			Message:    fmt.Sprintf("%s wins by abandonment!", winnerName),
+			Comment:    "abandonment",
+		}
+	}
+	log.Printf("Game %s ended: %s", session.ID, gameOverPayload.Message)
+
+	broadcastGameState(session)
+	broadcast(session, game.MessageTypeGameOver, gameOverPayload)
+}
+
+// leaveGame frees the client's seat and tells the opponent. The game's chat
+// log is dropped with the last player.
+func (gs *GameServer) leaveGame(c *client) {
+	session, player := c.seat()
+	session.ReleasePlayer(player)
+	log.Printf("Player %s (%s) disconnected from game %s", c.name, c.id, session.ID)
+	if len(session.PlayerList()) == 0 {
+		gs.forgetChatLog(session)
+	}
+
+	if opponent := session.GetOpponent(player); opponent != nil {
+		sendJSONMessage(session.PlayerConn(opponent), game.MessageTypeInfo, game.InfoPayload{
+			Message: fmt.Sprintf("Player %s has disconnected", c.name),
+		})
+	}
+}
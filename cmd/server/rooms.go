@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+)
+
+// createRoom opens a private room for c with the settings in the query
+// (tc, color, variant, rated) and sends c the code to share.
+func (gs *GameServer) createRoom(c *client, query url.Values) error {
+	settings, err := game.ParseRoomSettings(query.Get("tc"), query.Get("color"), query.Get("variant"), query.Get("rated"))
+	if err != nil {
+		return err
+	}
+	conn := c.connection()
+	room := gs.gameManager.CreateRoom(conn, c.name, settings)
+	c.room = room.Code
+	log.Printf("Player %s (%s) created room %s", c.name, c.id, room.Code)
+
+	kind := "Room"
+	if settings.Rated {
+		kind = "Rated room"
+	}
+	sendJSONMessage(conn, game.MessageTypeRoom, game.RoomPayload{
+		Code:        room.Code,
+		TimeControl: settings.TimeControl.String(),
+		Color:       settings.ColorString(),
+		Variant:     settings.Variant,
+		Rated:       settings.Rated,
+		Message:     fmt.Sprintf("%s %s created. Share the code with your friend to start the game.", kind, room.Code),
+	})
+	return nil
+}
+
+// joinRoom seats c in the room with the given code and starts the game.
+func (gs *GameServer) joinRoom(c *client, code string) error {
+	session, room, err := gs.gameManager.JoinRoom(code, c.connection(), c.name)
+	if err != nil {
+		return err
+	}
+	log.Printf("Player %s (%s) joined room %s hosted by %s", c.name, c.id, room.Code, room.HostName)
+	gs.startSession(session, room.Settings.TimeControl)
+	return nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+	"github.com/gorilla/websocket"
+)
+
+// LiveGame is one entry of the /games/live listing.
+type LiveGame struct {
+	GameID      string `json:"game_id"`
+	PlayerX     string `json:"player_x"`
+	PlayerO     string `json:"player_o"`
+	Moves       int    `json:"moves"`
+	CurrentTurn string `json:"current_turn"`
+	TimeControl string `json:"time_control,omitempty"`
+	Spectators  int    `json:"spectators"`
+}
+
+const spectatorHelp = "You are spectating. Commands:\n" +
+	"  board/show: Request board update\n" +
+	"  help: Show this help message\n" +
+	"  quit/exit: Stop watching"
+
+// liveGames lists the unfinished games, most watched first.
+func (gs *GameServer) liveGames() []LiveGame {
+	games := []LiveGame{}
+	for _, id := range gs.gameManager.GetActiveSessions() {
+		session := gs.gameManager.GetSession(id)
+		if session == nil || session.IsFinished() {
+			continue
+		}
+		board, moves := session.Snapshot()
+		live := LiveGame{
+			GameID:      session.ID,
+			PlayerX:     playerName(session, game.X),
+			PlayerO:     playerName(session, game.O),
+			Moves:       len(moves),
+			CurrentTurn: board.CurrentTurn.String(),
+			Spectators:  session.SpectatorCount(),
+		}
+		if tc := session.GetTimeControl(); tc.Timed() {
+			live.TimeControl = tc.String()
+		}
+		games = append(games, live)
+	}
+	sort.Slice(games, func(i, j int) bool {
+		if games[i].Spectators != games[j].Spectators {
+			return games[i].Spectators > games[j].Spectators
+		}
+		return games[i].GameID < games[j].GameID
+	})
+	return games
+}
+
+func (gs *GameServer) handleLiveGames(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, gs.liveGames())
+}
+
+// handleSpectator serves a connection watching gameID. Spectators get the
+// same broadcasts as the players but cannot act on the game, and follow the
+// players into rematches.
+func (gs *GameServer) handleSpectator(conn *websocket.Conn, name, gameID string) {
+	session := gs.gameManager.GetSession(gameID)
+	if session == nil {
+		sendJSONMessage(conn, game.MessageTypeError, game.ErrorPayload{Message: fmt.Sprintf("No game %s to watch", gameID)})
+		return
+	}
+
+	spectator := session.AddSpectator(conn, name)
+	log.Printf("%s is spectating game %s", name, gameID)
+	gs.spectatorsChanged(session, fmt.Sprintf("%s is now watching", name))
+
+	sendJSONMessage(conn, game.MessageTypeInfo, game.InfoPayload{
+		Message: fmt.Sprintf("Watching game %s: %s (X) vs %s (O)", gameID, playerName(session, game.X), playerName(session, game.O)),
+	})
+	sendJSONMessage(conn, game.MessageTypeGameState, session.GetGameStateForPlayer(nil))
+
+	for {
+		messageType, message, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		if messageType != websocket.TextMessage {
+			continue
+		}
+
+		msg, err := game.ParseClientMessage(message)
+		if err != nil {
+			if msg != nil {
+				respond(conn, msg, err)
+			} else {
+				sendJSONMessage(conn, game.MessageTypeError, game.ErrorPayload{Message: err.Error()})
+			}
+			continue
+		}
+		if msg.Type == game.ClientQuit {
+			sendJSONMessage(conn, game.MessageTypeInfo, game.InfoPayload{Message: "Goodbye!"})
+			respond(conn, msg, nil)
+			break
+		}
+		switch msg.Type {
+		case game.ClientBoard, game.ClientStatus:
+			sendJSONMessage(conn, game.MessageTypeGameState, session.Latest().GetGameStateForPlayer(nil))
+			respond(conn, msg, nil)
+		case game.ClientHelp:
+			sendJSONMessage(conn, game.MessageTypeInfo, game.InfoPayload{Message: spectatorHelp})
+			respond(conn, msg, nil)
+		default:
+			respond(conn, msg, fmt.Errorf("Spectators cannot %s", msg.Type))
+		}
+	}
+
+	session.RemoveSpectator(spectator)
+	session = session.Latest()
+	log.Printf("%s stopped spectating game %s", name, session.ID)
+	gs.spectatorsChanged(session, fmt.Sprintf("%s stopped watching", name))
+}
+
+// spectatorsChanged tells the players of an unfinished game about a new
+// spectator count.
+func (gs *GameServer) spectatorsChanged(session *game.GameSession, message string) {
+	if session.IsFinished() {
+		return
+	}
+	count := session.SpectatorCount()
+	for _, player := range session.PlayerList() {
+		sendJSONMessage(player.Conn, game.MessageTypeInfo, game.InfoPayload{
+			Message: fmt.Sprintf("%s (%d watching)", message, count),
+		})
+		sendGameStateToPlayer(session, &player)
+	}
+}
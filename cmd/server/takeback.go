@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+)
+
+func (gs *GameServer) requestTakeback(c *client) error {
+	session, player := c.seat()
+	conn := c.connection()
+	move, err := session.RequestTakeback(player)
+	if err != nil {
+		return fmt.Errorf("Cannot take back: %v", err)
+	}
+
+	if opponent := session.GetOpponent(player); opponent != nil {
+		sendJSONMessage(session.PlayerConn(opponent), game.MessageTypeTakebackOffer, game.TakebackOfferPayload{
+			OfferedBy: c.name,
+			Move:      move.ToString(),
+			Message:   fmt.Sprintf("Player %s wants to take back %s. Type ACCEPT_TAKEBACK or DECLINE_TAKEBACK", c.name, move.ToString()),
+		})
+	}
+	sendJSONMessage(conn, game.MessageTypeInfo, game.InfoPayload{Message: "Takeback request sent"})
+	return nil
+}
+
+func (gs *GameServer) declineTakeback(c *client) error {
+	session, player := c.seat()
+	conn := c.connection()
+	if err := session.DeclineTakeback(player); err != nil {
+		return fmt.Errorf("Cannot decline takeback: %v", err)
+	}
+
+	if opponent := session.GetOpponent(player); opponent != nil {
+		sendJSONMessage(session.PlayerConn(opponent), game.MessageTypeInfo, game.InfoPayload{Message: "Takeback request declined"})
+	}
+	sendJSONMessage(conn, game.MessageTypeInfo, game.InfoPayload{Message: "Takeback request declined"})
+	return nil
+}
+
+// acceptTakeback undoes the opponent's last move. The move is always undone
+// on the board once the session accepts; failing to drop it from the game
+// record is only logged.
+func (gs *GameServer) acceptTakeback(c *client) error {
+	session, player := c.seat()
+	opponent := session.GetOpponent(player)
+	move, err := session.AcceptTakeback(player)
+	if move == nil {
+		if errors.Is(err, game.ErrTimeUp) {
+			gs.announceFlag(session, player)
+		}
+		return fmt.Errorf("Cannot accept takeback: %v", err)
+	}
+	if err != nil {
+		log.Printf("Failed to remove move from the record of game %s: %v", session.ID, err)
+	}
+
+	takebackMsg := fmt.Sprintf("Takeback accepted: %s was taken back", move.ToString())
+	if opponent != nil {
+		takebackMsg = fmt.Sprintf("%s took back %s (%d takebacks left this game)", opponent.Name, move.ToString(), session.TakebacksLeft())
+	}
+	broadcast(session, game.MessageTypeInfo, game.InfoPayload{Message: takebackMsg})
+	broadcastGameState(session)
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/ugn"
+)
+
+func runCat(args []string) error {
+	fs := flag.NewFlagSet("cat", flag.ContinueOnError)
+	out := fs.String("o", "", "output file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	w, err := openOutput(*out)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	writer := ugn.NewWriter(w)
+	for _, name := range files {
+		games, err := readGames(name)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		for _, g := range games {
+			if err := writer.Write(g); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func runSplit(args []string) error {
+	fs := flag.NewFlagSet("split", flag.ContinueOnError)
+	dir := fs.String("dir", ".", "directory to write the games into")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("split takes a single file")
+	}
+	name := "-"
+	if fs.NArg() == 1 {
+		name = fs.Arg(0)
+	}
+
+	games, err := readGames(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*dir, 0755); err != nil {
+		return err
+	}
+
+	used := make(map[string]bool)
+	for i, g := range games {
+		filename := g.GenerateFilename()
+		if g.Metadata.GameID == "" || used[filename] || !safeFilename(filename) {
+			filename = fmt.Sprintf("game_%04d.ugn", i+1)
+		}
+		used[filename] = true
+		path := filepath.Join(*dir, filename)
+		if err := g.WriteUGNFile(path); err != nil {
+			return err
+		}
+		fmt.Println(path)
+	}
+	return nil
+}
+
+// safeFilename reports whether a name built from a game's tags stays inside
+// the output directory.
+func safeFilename(name string) bool {
+	return !strings.ContainsAny(name, `/\`) && !strings.Contains(name, "..") && filepath.Base(name) == name
+}
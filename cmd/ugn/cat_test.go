@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitKeepsHostileNamesInDir(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "out")
+	input := filepath.Join(root, "games.ugn")
+	games := `[GameID "x/../../pwn"]
+[Date "2026-01-01"]
+[Time "12:00:00"]
+[PlayerX "alice"]
+[PlayerO "bob"]
+[Result "*"]
+
+E5 E1 *
+
+[GameID "ok"]
+[Date "2026-01-01"]
+[Time "12:00:00"]
+[PlayerX "alice"]
+[PlayerO "bob"]
+[Result "*"]
+
+E5 *
+`
+	if err := os.WriteFile(input, []byte(games), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runSplit([]string{"-dir", dir, input}); err != nil {
+		t.Fatalf("split failed: %v", err)
+	}
+
+	for _, name := range []string{"game_0001.ugn", "20260101_120000_ok.ugn"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected %s in the output directory: %v", name, err)
+		}
+	}
+	if matches, _ := filepath.Glob(filepath.Join(root, "*pwn*")); len(matches) != 0 {
+		t.Errorf("Game written outside the output directory: %v", matches)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/ugn"
+)
+
+func runConvert(args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	from := fs.String("from", "ugn", "input format: ugn, rowcol, index, boardcell or json")
+	to := fs.String("to", "ugn", "output format: ugn, rowcol, index, boardcell or json")
+	out := fs.String("o", "", "output file (default stdout)")
+	gameID := fs.String("id", "imported", "GameID for games imported from plain move formats")
+	playerX := fs.String("player-x", "?", "PlayerX for games imported from plain move formats")
+	playerO := fs.String("player-o", "?", "PlayerO for games imported from plain move formats")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	fromFormat, err := ugn.ParseFormat(*from)
+	if err != nil {
+		return err
+	}
+	toFormat, err := ugn.ParseFormat(*to)
+	if err != nil {
+		return err
+	}
+
+	in, err := openInput(fs.Args())
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	var games []*ugn.UGNGame
+	switch fromFormat {
+	case ugn.FormatUGN:
+		games, err = ugn.NewReader(in).ReadAll()
+	case ugn.FormatJSON:
+		var data []byte
+		if data, err = io.ReadAll(in); err == nil {
+			games, err = ugn.DecodeJSONGames(data)
+		}
+	default:
+		var data []byte
+		if data, err = io.ReadAll(in); err != nil {
+			break
+		}
+		moves, err := ugn.ParseMoves(string(data), fromFormat)
+		if err != nil {
+			return err
+		}
+		g, err := ugn.NewGameFromMoves(*gameID, *playerX, *playerO, moves)
+		if err != nil {
+			return err
+		}
+		games = []*ugn.UGNGame{g}
+	}
+	if err != nil {
+		return err
+	}
+	if len(games) == 0 {
+		return fmt.Errorf("no games in input")
+	}
+
+	w, err := openOutput(*out)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	switch toFormat {
+	case ugn.FormatUGN:
+		writer := ugn.NewWriter(w)
+		for _, g := range games {
+			if err := writer.Write(g); err != nil {
+				return err
+			}
+		}
+	case ugn.FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if len(games) == 1 {
+			return enc.Encode(games[0].ToJSON())
+		}
+		all := make([]ugn.JSONGame, 0, len(games))
+		for _, g := range games {
+			all = append(all, g.ToJSON())
+		}
+		return enc.Encode(all)
+	default:
+		if len(games) > 1 {
+			return fmt.Errorf("%s holds a single game, input has %d", toFormat, len(games))
+		}
+		text, err := ugn.FormatMoves(games[0], toFormat)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, text)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/ugn"
+)
+
+func runFmt(args []string) error {
+	fs := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	write := fs.Bool("w", false, "rewrite files in place instead of printing to stdout")
+	list := fs.Bool("l", false, "list files whose formatting differs")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	files := fs.Args()
+	if len(files) == 0 {
+		if *write || *list {
+			return fmt.Errorf("-w and -l need file arguments")
+		}
+		files = []string{"-"}
+	}
+
+	for _, name := range files {
+		games, err := readGames(name)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		var buf bytes.Buffer
+		w := ugn.NewWriter(&buf)
+		for _, g := range games {
+			if err := w.Write(g); err != nil {
+				return err
+			}
+		}
+
+		if *list || *write {
+			original, err := os.ReadFile(name)
+			if err != nil {
+				return err
+			}
+			if bytes.Equal(original, buf.Bytes()) {
+				continue
+			}
+			if *list {
+				fmt.Println(name)
+			}
+			if *write {
+				if err := replaceFile(name, buf.Bytes()); err != nil {
+					return fmt.Errorf("%s: %v", name, err)
+				}
+			}
+			continue
+		}
+		os.Stdout.Write(buf.Bytes())
+	}
+	return nil
+}
+
+func replaceFile(name string, data []byte) error {
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+	"github.com/eshahhh/ultimatetictactoe/internal/render"
+	"github.com/eshahhh/ultimatetictactoe/internal/ugn"
+)
+
+func runGIF(args []string) error {
+	fs := flag.NewFlagSet("gif", flag.ContinueOnError)
+	output := fs.String("o", "", "output file (default stdout)")
+	size := fs.Int("size", render.DefaultSize, "image width and height in pixels")
+	delay := fs.Duration("delay", render.DefaultDelay, "time between moves")
+	finalDelay := fs.Duration("final", render.DefaultFinalDelay, "time the final position is shown")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("gif takes a single file")
+	}
+
+	g, err := ugn.ParseUGNFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	moves := make([]game.Move, len(g.Moves))
+	for i, m := range g.Moves {
+		moves[i] = game.Move{BoardIndex: m.BoardIndex, Position: m.Position}
+	}
+
+	out, err := openOutput(*output)
+	if err != nil {
+		return err
+	}
+	err = render.GIF(out, moves, render.GIFOptions{Size: *size, Delay: *delay, FinalDelay: *finalDelay})
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/ugn"
+)
+
+type command struct {
+	usage   string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = map[string]command{
+	"cat":      {"cat [-o out] files...", "concatenate games into one multi-game stream", runCat},
+	"convert":  {"convert [-from fmt] [-to fmt] [-o out] [file]", "convert between ugn, rowcol, index, boardcell and json", runConvert},
+	"fmt":      {"fmt [-w] [-l] files...", "rewrite games in canonical UGN formatting", runFmt},
+	"gif":      {"gif [-delay d] [-size N] [-o out.gif] file", "render an animated replay of a game", runGIF},
+	"index":    {"index [-dir games]", "build or refresh the archive index", runIndex},
+	"puzzles":  {"puzzles [-dir games] [-selfplay N] [-o out]", "mine archived and self-play games for puzzles", runPuzzles},
+	"search":   {"search [-dir games] [-player p] [-moves m] ...", "query the archive index", runSearch},
+	"show":     {"show [-ply N] [-game N] file", "print the board after N moves", runShow},
+	"split":    {"split [-dir dir] file", "write each game of a multi-game file to its own file", runSplit},
+	"stats":    {"stats [-top N] files...", "summarize results, lengths, openings and players", runStats},
+	"validate": {"validate files...", "replay games and report illegal moves and bad annotations", runValidate},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage(os.Stderr)
+		os.Exit(2)
+	}
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "--help" {
+		printUsage(os.Stdout)
+		return
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "ugn: unknown command %q\n\n", name)
+		printUsage(os.Stderr)
+		os.Exit(2)
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "ugn %s: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: ugn <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-46s %s\n", commands[name].usage, commands[name].summary)
+	}
+}
+
+// openInput returns the named file, or stdin when no file (or "-") is given.
+func openInput(args []string) (io.ReadCloser, error) {
+	if len(args) == 0 || args[0] == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	file, err := os.Open(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", args[0], err)
+	}
+	return file, nil
+}
+
+// openOutput returns the named file, or stdout when the name is empty or "-".
+func openOutput(name string) (io.WriteCloser, error) {
+	if name == "" || name == "-" {
+		return nopWriteCloser{os.Stdout}, nil
+	}
+	file, err := os.Create(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %v", name, err)
+	}
+	return file, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// readGames reads every game from the named file, or from stdin for "-".
+func readGames(name string) ([]*ugn.UGNGame, error) {
+	in, err := openInput([]string{name})
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	return ugn.NewReader(in).ReadAll()
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/puzzle"
+	"github.com/eshahhh/ultimatetictactoe/internal/ugn"
+)
+
+func runPuzzles(args []string) error {
+	fs := flag.NewFlagSet("puzzles", flag.ContinueOnError)
+	dir := fs.String("dir", "games", "game archive directory to mine (empty to skip)")
+	selfPlay := fs.Int("selfplay", 0, "number of self-play games to mine as well")
+	depth := fs.Int("depth", 2, "search depth of the self-play bots")
+	maxMoves := fs.Int("max-moves", puzzle.DefaultMaxMoves, "longest forced win to look for, in the solver's moves")
+	maxNodes := fs.Int("max-nodes", puzzle.DefaultMaxNodes, "solver budget per position")
+	seed := fs.Int64("seed", time.Now().UnixNano(), "self-play random seed")
+	output := fs.String("o", "", "output puzzle file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	miner := puzzle.NewMiner(*maxMoves, *maxNodes)
+	var puzzles []*puzzle.Puzzle
+	if *dir != "" {
+		games, err := ugn.ParseUGNDir(*dir)
+		if games == nil {
+			return err
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+		for _, g := range games {
+			puzzles = append(puzzles, miner.MineGame(g)...)
+		}
+		fmt.Fprintf(os.Stderr, "%d archived game(s) mined\n", len(games))
+	}
+	rng := rand.New(rand.NewSource(*seed))
+	for i := 0; i < *selfPlay; i++ {
+		moves := puzzle.SelfPlay(rng, *depth, 6)
+		puzzles = append(puzzles, miner.MineMoves(moves, puzzle.SelfPlaySource, "bot", "bot")...)
+	}
+	fmt.Fprintf(os.Stderr, "%d puzzle(s) found\n", len(puzzles))
+
+	out, err := openOutput(*output)
+	if err != nil {
+		return err
+	}
+	err = puzzle.Write(out, puzzles)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/archive"
+)
+
+func runIndex(args []string) error {
+	fs := flag.NewFlagSet("index", flag.ContinueOnError)
+	dir := fs.String("dir", "games", "game archive directory")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ix, err := archive.Open(*dir)
+	if err != nil {
+		return err
+	}
+	changed, err := ix.Update()
+	fmt.Printf("%d game(s) indexed, %d changed\n", ix.Len(), changed)
+	return err
+}
+
+func runSearch(args []string) error {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	dir := fs.String("dir", "games", "game archive directory")
+	player := fs.String("player", "", "games played by this player")
+	as := fs.String("as", "", "with -player: X or O")
+	outcome := fs.String("outcome", "", "with -player: win, loss or draw")
+	result := fs.String("result", "", "X, O, Draw or *")
+	opening := fs.String("opening", "", "opening move prefix, e.g. \"E5 E1\"")
+	moves := fs.String("moves", "", "games reaching the position after these moves")
+	since := fs.String("since", "", "games on or after this date (YYYY-MM-DD)")
+	until := fs.String("until", "", "games on or before this date (YYYY-MM-DD)")
+	limit := fs.Int("limit", 0, "maximum number of results")
+	asJSON := fs.Bool("json", false, "print results as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	values := map[string][]string{
+		"player": {*player}, "as": {*as}, "outcome": {*outcome}, "result": {*result},
+		"opening": {*opening}, "moves": {*moves}, "since": {*since}, "until": {*until},
+		"limit": {fmt.Sprint(*limit)},
+	}
+	q, err := archive.ParseQuery(values)
+	if err != nil {
+		return err
+	}
+
+	ix, err := archive.Open(*dir)
+	if err != nil {
+		return err
+	}
+	if _, err := ix.Update(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+
+	results := ix.Search(q)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	for _, e := range results {
+		fmt.Printf("%s  %s %s  %s vs %s  %s  %d moves\n",
+			ix.Path(e), e.Date, e.Time, e.PlayerX, e.PlayerO, e.Result, e.Length)
+	}
+	fmt.Printf("%d game(s)\n", len(results))
+	return nil
+}
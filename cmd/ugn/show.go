@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+	"github.com/eshahhh/ultimatetictactoe/internal/ugn"
+)
+
+func runShow(args []string) error {
+	fs := flag.NewFlagSet("show", flag.ContinueOnError)
+	ply := fs.Int("ply", -1, "number of moves to play before showing the board (default: all)")
+	gameNum := fs.Int("game", 1, "game number within a multi-game file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("show takes a single file")
+	}
+
+	g, err := selectGame(fs.Args(), *gameNum)
+	if err != nil {
+		return err
+	}
+	n := *ply
+	if n < 0 || n > len(g.Moves) {
+		n = len(g.Moves)
+	}
+
+	board := game.NewUltimateBoard()
+	for i, m := range g.Moves[:n] {
+		if err := board.MakeMove(m.BoardIndex, m.Position); err != nil {
+			return fmt.Errorf("move %d (%s): %v", i+1, m.ToString(), err)
+		}
+	}
+
+	fmt.Printf("%s vs %s, after %d of %d moves", g.Metadata.PlayerX, g.Metadata.PlayerO, n, len(g.Moves))
+	if n > 0 {
+		fmt.Printf(" (last: %s)", g.Moves[n-1].ToString())
+	}
+	fmt.Print("\n\n")
+	fmt.Print(board.String())
+	return nil
+}
+
+func selectGame(args []string, number int) (*ugn.UGNGame, error) {
+	name := "-"
+	if len(args) > 0 {
+		name = args[0]
+	}
+	games, err := readGames(name)
+	if err != nil {
+		return nil, err
+	}
+	if number < 1 || number > len(games) {
+		return nil, fmt.Errorf("%s has %d game(s), cannot select game %d", name, len(games), number)
+	}
+	return games[number-1], nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+)
+
+func runStats(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	top := fs.Int("top", 5, "number of openings and players to list")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	results := make(map[string]int)
+	openings := make(map[string]int)
+	players := make(map[string]int)
+	total, moves, smallWins, smallDraws := 0, 0, 0, 0
+	shortest, longest := -1, 0
+
+	for _, name := range files {
+		games, err := readGames(name)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		for _, g := range games {
+			total++
+			n := len(g.Moves)
+			moves += n
+			if shortest < 0 || n < shortest {
+				shortest = n
+			}
+			if n > longest {
+				longest = n
+			}
+			switch g.Metadata.Result {
+			case "X", "O", "Draw":
+				results[g.Metadata.Result]++
+			default:
+				results["unfinished"]++
+			}
+			for _, m := range g.Moves {
+				if m.SmallWin {
+					smallWins++
+				}
+				if m.SmallDraw {
+					smallDraws++
+				}
+			}
+			if n >= 2 {
+				openings[g.Moves[0].ToString()+" "+g.Moves[1].ToString()]++
+			} else if n == 1 {
+				openings[g.Moves[0].ToString()]++
+			}
+			for _, player := range []string{g.Metadata.PlayerX, g.Metadata.PlayerO} {
+				if player != "" {
+					players[player]++
+				}
+			}
+		}
+	}
+
+	if total == 0 {
+		fmt.Println("No games")
+		return nil
+	}
+
+	fmt.Printf("Games:        %d\n", total)
+	fmt.Printf("X wins:       %d (%.1f%%)\n", results["X"], percent(results["X"], total))
+	fmt.Printf("O wins:       %d (%.1f%%)\n", results["O"], percent(results["O"], total))
+	fmt.Printf("Draws:        %d (%.1f%%)\n", results["Draw"], percent(results["Draw"], total))
+	fmt.Printf("Unfinished:   %d (%.1f%%)\n", results["unfinished"], percent(results["unfinished"], total))
+	fmt.Printf("Moves:        %d total, %.1f average, %d shortest, %d longest\n",
+		moves, float64(moves)/float64(total), shortest, longest)
+	fmt.Printf("Small boards: %d won, %d drawn\n", smallWins, smallDraws)
+
+	fmt.Println("\nMost common openings:")
+	for _, e := range topCounts(openings, *top) {
+		fmt.Printf("  %-8s %d\n", e.key, e.count)
+	}
+	fmt.Println("\nMost active players:")
+	for _, e := range topCounts(players, *top) {
+		fmt.Printf("  %-20s %d\n", e.key, e.count)
+	}
+	return nil
+}
+
+type countEntry struct {
+	key   string
+	count int
+}
+
+func topCounts(counts map[string]int, n int) []countEntry {
+	entries := make([]countEntry, 0, len(counts))
+	for k, c := range counts {
+		entries = append(entries, countEntry{k, c})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].count != entries[j].count {
+			return entries[i].count > entries[j].count
+		}
+		return entries[i].key < entries[j].key
+	})
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
+
+func percent(n, total int) float64 {
+	return 100 * float64(n) / float64(total)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/ugn"
+)
+
+func runValidate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	problems := 0
+	for _, name := range files {
+		games, err := readGames(name)
+		if err != nil {
+			fmt.Printf("%s: %v\n", name, err)
+			problems++
+			continue
+		}
+		for i, g := range games {
+			for _, verr := range ugn.Validate(g) {
+				if verr.Pos.Line == 0 {
+					fmt.Printf("%s: game %d: %s\n", name, i+1, verr.Message)
+				} else {
+					fmt.Printf("%s:%s: %s\n", name, verr.Pos, verr.Message)
+				}
+				problems++
+			}
+		}
+	}
+	if problems > 0 {
+		return fmt.Errorf("%d problem(s) found", problems)
+	}
+	return nil
+}
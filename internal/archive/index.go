@@ -0,0 +1,246 @@
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+	"github.com/eshahhh/ultimatetictactoe/internal/ugn"
+)
+
+const (
+	IndexFile    = "index.json"
+	OpeningPlies = 6
+)
+
+// Entry is the indexed summary of one archived UGN file.
+type Entry struct {
+	File      string    `json:"file"`
+	ModTime   time.Time `json:"mod_time"`
+	Size      int64     `json:"size"`
+	GameID    string    `json:"game_id"`
+	Date      string    `json:"date"`
+	Time      string    `json:"time"`
+	PlayerX   string    `json:"player_x"`
+	PlayerO   string    `json:"player_o"`
+	Result    string    `json:"result"`
+	Comment   string    `json:"comment,omitempty"`
+	Length    int       `json:"length"`
+	Opening   string    `json:"opening"`             // first OpeningPlies moves without annotations
+	Positions []uint64  `json:"positions,omitempty"` // UltimateBoard.Hash of every position reached
+}
+
+// Index keeps a summary of every game in a directory, stored as index.json
+// in that directory and refreshed incrementally by Update.
+type Index struct {
+	dir        string
+	entries    map[string]*Entry
+	byPosition map[uint64][]string
+	scanned    time.Time // modification time of dir when Update last listed it
+	mutex      sync.RWMutex
+}
+
+func Open(dir string) (*Index, error) {
+	ix := &Index{
+		dir:     dir,
+		entries: make(map[string]*Entry),
+	}
+	data, err := os.ReadFile(filepath.Join(dir, IndexFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read index: %v", err)
+	}
+	if err == nil {
+		var entries []*Entry
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("failed to parse index: %v", err)
+		}
+		for _, e := range entries {
+			ix.entries[e.File] = e
+		}
+	}
+	ix.rebuildPositions()
+	return ix, nil
+}
+
+func (ix *Index) Dir() string {
+	return ix.dir
+}
+
+// Update indexes new and changed files and drops entries for deleted ones.
+// Files whose size and modification time are unchanged are not re-read.
+func (ix *Index) Update() (changed int, err error) {
+	dirInfo, err := os.Stat(ix.dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list games: %v", err)
+	}
+	files, err := filepath.Glob(filepath.Join(ix.dir, "*.ugn"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list games: %v", err)
+	}
+
+	ix.mutex.Lock()
+	defer ix.mutex.Unlock()
+	ix.scanned = dirInfo.ModTime()
+
+	seen := make(map[string]bool, len(files))
+	var errs []string
+	for _, path := range files {
+		name := filepath.Base(path)
+		seen[name] = true
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if e, ok := ix.entries[name]; ok && e.Size == info.Size() && e.ModTime.Equal(info.ModTime()) {
+			continue
+		}
+		entry, err := indexFile(path, info)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		ix.entries[name] = entry
+		changed++
+	}
+	for name := range ix.entries {
+		if !seen[name] {
+			delete(ix.entries, name)
+			changed++
+		}
+	}
+
+	if changed > 0 {
+		ix.rebuildPositionsLocked()
+		if err := ix.saveLocked(); err != nil {
+			return changed, err
+		}
+	}
+	if len(errs) > 0 {
+		return changed, fmt.Errorf("failed to index %d file(s): %s", len(errs), strings.Join(errs, "; "))
+	}
+	return changed, nil
+}
+
+// Refresh runs Update only if the directory's modification time has changed
+// since the last scan, which it does whenever a game file is added, renamed
+// into place or removed. Files edited in place are left to Update.
+func (ix *Index) Refresh() (changed int, err error) {
+	info, err := os.Stat(ix.dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list games: %v", err)
+	}
+	ix.mutex.RLock()
+	current := ix.scanned.Equal(info.ModTime())
+	ix.mutex.RUnlock()
+	if current {
+		return 0, nil
+	}
+	return ix.Update()
+}
+
+func indexFile(path string, info os.FileInfo) (*Entry, error) {
+	g, err := ugn.ParseUGNFile(path)
+	if err != nil {
+		return nil, err
+	}
+	entry := &Entry{
+		File:    filepath.Base(path),
+		ModTime: info.ModTime(),
+		Size:    info.Size(),
+		GameID:  g.Metadata.GameID,
+		Date:    g.Metadata.Date,
+		Time:    g.Metadata.Time,
+		PlayerX: g.Metadata.PlayerX,
+		PlayerO: g.Metadata.PlayerO,
+		Result:  g.Metadata.Result,
+		Comment: g.Metadata.Comment,
+		Length:  len(g.Moves),
+	}
+
+	var opening []string
+	seen := make(map[uint64]bool)
+	board, err := g.Replay(func(ply int, board *game.UltimateBoard, move ugn.UGNMove) error {
+		if ply < OpeningPlies {
+			plain := game.Move{BoardIndex: move.BoardIndex, Position: move.Position}
+			opening = append(opening, plain.ToString())
+		}
+		if h := board.Hash(); !seen[h] {
+			seen[h] = true
+			entry.Positions = append(entry.Positions, h)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if h := board.Hash(); !seen[h] {
+		entry.Positions = append(entry.Positions, h)
+	}
+	entry.Opening = strings.Join(opening, " ")
+	return entry, nil
+}
+
+func (ix *Index) rebuildPositions() {
+	ix.mutex.Lock()
+	defer ix.mutex.Unlock()
+	ix.rebuildPositionsLocked()
+}
+
+func (ix *Index) rebuildPositionsLocked() {
+	ix.byPosition = make(map[uint64][]string)
+	for name, e := range ix.entries {
+		for _, h := range e.Positions {
+			ix.byPosition[h] = append(ix.byPosition[h], name)
+		}
+	}
+}
+
+func (ix *Index) Save() error {
+	ix.mutex.RLock()
+	defer ix.mutex.RUnlock()
+	return ix.saveLocked()
+}
+
+func (ix *Index) saveLocked() error {
+	entries := make([]*Entry, 0, len(ix.entries))
+	for _, e := range ix.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].File < entries[j].File
+	})
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode index: %v", err)
+	}
+	path := filepath.Join(ix.dir, IndexFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write index: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write index: %v", err)
+	}
+	return nil
+}
+
+func (ix *Index) Len() int {
+	ix.mutex.RLock()
+	defer ix.mutex.RUnlock()
+	return len(ix.entries)
+}
+
+// Entries returns all indexed games, oldest first.
+func (ix *Index) Entries() []*Entry {
+	return ix.Search(Query{})
+}
+
+func (ix *Index) Path(e *Entry) string {
+	return filepath.Join(ix.dir, e.File)
+}
@@ -0,0 +1,137 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+	"github.com/eshahhh/ultimatetictactoe/internal/ugn"
+)
+
+func writeGame(t *testing.T, dir, id, playerX, playerO, result, moves string) {
+	t.Helper()
+	parsed, err := ParseMoveList(moves)
+	if err != nil {
+		t.Fatalf("Bad moves %q: %v", moves, err)
+	}
+	g, err := ugn.NewGameFromMoves(id, playerX, playerO, parsed)
+	if err != nil {
+		t.Fatalf("Bad game %s: %v", id, err)
+	}
+	g.SetResult(result)
+	if err := g.WriteUGNFile(filepath.Join(dir, id+".ugn")); err != nil {
+		t.Fatalf("Failed to write %s: %v", id, err)
+	}
+}
+
+func ids(entries []*Entry) []string {
+	var out []string
+	for _, e := range entries {
+		out = append(out, e.GameID)
+	}
+	return out
+}
+
+func TestIndexSearch(t *testing.T) {
+	dir := t.TempDir()
+	writeGame(t, dir, "g1", "alice", "bob", "X", "E5 E1 A5")
+	writeGame(t, dir, "g2", "bob", "alice", "X", "E5 E1 A9")
+	writeGame(t, dir, "g3", "carol", "alice", "Draw", "A5 E1")
+	writeGame(t, dir, "g4", "bob", "Alice", "O", "C3 C5")
+
+	ix, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if changed, err := ix.Update(); err != nil || changed != 4 {
+		t.Fatalf("Expected 4 new entries, got %d (%v)", changed, err)
+	}
+
+	tests := []struct {
+		name     string
+		query    Query
+		expected []string
+	}{
+		{"lost as O", Query{Player: "alice", As: "O", Outcome: "loss"}, []string{"g2"}},
+		{"won", Query{Player: "alice", Outcome: "win"}, []string{"g1", "g4"}},
+		{"draws", Query{Player: "alice", Outcome: "draw"}, []string{"g3"}},
+		{"opening", Query{Opening: "e5 e1"}, []string{"g1", "g2"}},
+		{"position", Query{Position: []game.Move{{BoardIndex: 4, Position: 4}, {BoardIndex: 4, Position: 0}}}, []string{"g1", "g2"}},
+		{"result", Query{Result: "Draw"}, []string{"g3"}},
+	}
+	for _, test := range tests {
+		got := ids(ix.Search(test.query))
+		if len(got) != len(test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.expected[i] {
+				t.Errorf("%s: expected %v, got %v", test.name, test.expected, got)
+				break
+			}
+		}
+	}
+}
+
+func TestIndexIncrementalUpdate(t *testing.T) {
+	dir := t.TempDir()
+	writeGame(t, dir, "g1", "alice", "bob", "X", "E5 E1")
+	writeGame(t, dir, "g2", "bob", "alice", "O", "E5 E9")
+
+	ix, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	ix.Update()
+
+	reopened, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	if changed, _ := reopened.Update(); changed != 0 {
+		t.Errorf("Expected saved index to be up to date, %d entries changed", changed)
+	}
+
+	os.Remove(filepath.Join(dir, "g1.ugn"))
+	writeGame(t, dir, "g3", "carol", "dave", "Draw", "A1")
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(filepath.Join(dir, "g3.ugn"), later, later)
+
+	if changed, err := reopened.Update(); err != nil || changed != 2 {
+		t.Errorf("Expected 1 removal and 1 addition, got %d (%v)", changed, err)
+	}
+	if got := ids(reopened.Search(Query{GameID: "g1"})); len(got) != 0 {
+		t.Errorf("Expected deleted game to be dropped, got %v", got)
+	}
+	if got := ids(reopened.Search(Query{Player: "carol"})); len(got) != 1 || got[0] != "g3" {
+		t.Errorf("Expected new game to be indexed, got %v", got)
+	}
+}
+
+func TestIndexRefreshOnlyWhenDirChanges(t *testing.T) {
+	dir := t.TempDir()
+	writeGame(t, dir, "g1", "alice", "bob", "X", "E5 E1")
+
+	ix, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	ix.Update()
+	ix.Refresh() // picks up the directory change from saving index.json
+	info, _ := os.Stat(dir)
+
+	writeGame(t, dir, "g2", "carol", "dave", "O", "E5 E9")
+	os.Chtimes(dir, info.ModTime(), info.ModTime())
+	if changed, err := ix.Refresh(); err != nil || changed != 0 {
+		t.Errorf("Expected no rescan of an unchanged directory, got %d (%v)", changed, err)
+	}
+
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(dir, later, later)
+	if changed, err := ix.Refresh(); err != nil || changed != 1 {
+		t.Errorf("Expected the new game to be indexed, got %d (%v)", changed, err)
+	}
+}
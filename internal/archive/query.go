@@ -0,0 +1,194 @@
+package archive
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+)
+
+// Query selects indexed games. Empty fields match everything.
+type Query struct {
+	Player   string // game played by this player (case-insensitive)
+	As       string // "X" or "O": Player had this symbol
+	Outcome  string // "win", "loss" or "draw" from Player's point of view
+	Result   string // "X", "O", "Draw" or "*"
+	GameID   string
+	Opening  string // move prefix, e.g. "E5 E1"
+	Position []game.Move
+	Since    string // YYYY-MM-DD, inclusive
+	Until    string // YYYY-MM-DD, inclusive
+	Limit    int
+}
+
+// ParseMoveList reads moves separated by spaces or commas, e.g. "E5,E1 A5".
+func ParseMoveList(s string) ([]game.Move, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	moves := make([]game.Move, 0, len(fields))
+	for _, field := range fields {
+		move, err := game.ParseMove(strings.TrimRight(field, "!/%#"))
+		if err != nil {
+			return nil, err
+		}
+		moves = append(moves, *move)
+	}
+	return moves, nil
+}
+
+func (q Query) Validate() error {
+	switch strings.ToUpper(q.As) {
+	case "", "X", "O":
+	default:
+		return fmt.Errorf("as must be X or O")
+	}
+	switch strings.ToLower(q.Outcome) {
+	case "", "win", "loss", "draw":
+	default:
+		return fmt.Errorf("outcome must be win, loss or draw")
+	}
+	if (q.As != "" || q.Outcome != "") && q.Player == "" {
+		return fmt.Errorf("as and outcome need a player")
+	}
+	return nil
+}
+
+// Search returns matching games, oldest first.
+func (ix *Index) Search(q Query) []*Entry {
+	var positionHash uint64
+	if len(q.Position) > 0 {
+		board := game.NewUltimateBoard()
+		for _, move := range q.Position {
+			if err := board.MakeMove(move.BoardIndex, move.Position); err != nil {
+				return nil
+			}
+		}
+		positionHash = board.Hash()
+	}
+
+	ix.mutex.RLock()
+	defer ix.mutex.RUnlock()
+
+	candidates := make([]*Entry, 0, len(ix.entries))
+	if len(q.Position) > 0 {
+		for _, name := range ix.byPosition[positionHash] {
+			candidates = append(candidates, ix.entries[name])
+		}
+	} else {
+		for _, e := range ix.entries {
+			candidates = append(candidates, e)
+		}
+	}
+
+	var results []*Entry
+	for _, e := range candidates {
+		if q.matches(e) {
+			results = append(results, e)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Date+results[i].Time != results[j].Date+results[j].Time {
+			return results[i].Date+results[i].Time < results[j].Date+results[j].Time
+		}
+		return results[i].File < results[j].File
+	})
+	if q.Limit > 0 && len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results
+}
+
+func (q Query) matches(e *Entry) bool {
+	if q.GameID != "" && e.GameID != q.GameID {
+		return false
+	}
+	if q.Result != "" && !resultMatches(e.Result, q.Result) {
+		return false
+	}
+	if q.Since != "" && e.Date < q.Since {
+		return false
+	}
+	if q.Until != "" && e.Date > q.Until {
+		return false
+	}
+	if q.Opening != "" && !strings.HasPrefix(e.Opening+" ", normalizeMoves(q.Opening)+" ") {
+		return false
+	}
+	if q.Player == "" {
+		return true
+	}
+
+	var symbol string
+	switch {
+	case strings.EqualFold(e.PlayerX, q.Player) && (q.As == "" || strings.EqualFold(q.As, "X")):
+		symbol = "X"
+	case strings.EqualFold(e.PlayerO, q.Player) && (q.As == "" || strings.EqualFold(q.As, "O")):
+		symbol = "O"
+	default:
+		return false
+	}
+
+	switch strings.ToLower(q.Outcome) {
+	case "win":
+		return e.Result == symbol
+	case "loss":
+		return (e.Result == "X" || e.Result == "O") && e.Result != symbol
+	case "draw":
+		return e.Result == "Draw"
+	}
+	return true
+}
+
+func resultMatches(result, want string) bool {
+	if want == "*" {
+		return result != "X" && result != "O" && result != "Draw"
+	}
+	return strings.EqualFold(result, want)
+}
+
+func normalizeMoves(s string) string {
+	moves, err := ParseMoveList(s)
+	if err != nil {
+		return strings.ToUpper(s)
+	}
+	parts := make([]string, len(moves))
+	for i := range moves {
+		parts[i] = moves[i].ToString()
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParseQuery reads a query from URL parameters: player, as, outcome, result,
+// game_id, opening, moves (position reached by these moves), since, until
+// and limit.
+func ParseQuery(values url.Values) (Query, error) {
+	q := Query{
+		Player:  values.Get("player"),
+		As:      strings.ToUpper(values.Get("as")),
+		Outcome: strings.ToLower(values.Get("outcome")),
+		Result:  values.Get("result"),
+		GameID:  values.Get("game_id"),
+		Opening: values.Get("opening"),
+		Since:   values.Get("since"),
+		Until:   values.Get("until"),
+	}
+	if moves := values.Get("moves"); moves != "" {
+		position, err := ParseMoveList(moves)
+		if err != nil {
+			return q, fmt.Errorf("invalid moves: %v", err)
+		}
+		q.Position = position
+	}
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			return q, fmt.Errorf("invalid limit %q", limit)
+		}
+		q.Limit = n
+	}
+	return q, q.Validate()
+}
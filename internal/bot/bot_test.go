@@ -0,0 +1,73 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+	"github.com/eshahhh/ultimatetictactoe/internal/ugn"
+)
+
+func TestSearchFindsWinningMove(t *testing.T) {
+	board := game.NewUltimateBoard()
+	// X owns boards A and B and can complete the top row by winning C.
+	for i := 0; i < 3; i++ {
+		board.Boards[0].MakeMove(i, game.X)
+		board.Boards[1].MakeMove(i, game.X)
+	}
+	board.Boards[2].MakeMove(0, game.X)
+	board.Boards[2].MakeMove(1, game.X)
+	board.ActiveBoard = 2
+
+	move, score, ok := Search(board, 2)
+	if !ok || move.BoardIndex != 2 || move.Position != 2 {
+		t.Errorf("Expected C3, got %s", move.ToString())
+	}
+	if score != winScore {
+		t.Errorf("Expected winning score %d, got %d", winScore, score)
+	}
+
+	board.MakeMove(2, 2)
+	if _, _, ok := Search(board, 2); ok {
+		t.Error("Expected no move on a finished board")
+	}
+}
+
+func TestHumanBotFollowsArchive(t *testing.T) {
+	g := ugn.NewUGNGame("test", "alice", "bob")
+	g.AddMove(ugn.UGNMove{BoardIndex: 4, Position: 4})
+	g.AddMove(ugn.UGNMove{BoardIndex: 4, Position: 0})
+
+	games := []*ugn.UGNGame{g, g, g}
+	stats, err := BuildStats(games, nil, 1500)
+	if err != nil {
+		t.Fatalf("BuildStats failed: %v", err)
+	}
+
+	hb := NewHumanBot(stats, 1500, 1)
+	move, ok := hb.ChooseMove(game.NewUltimateBoard())
+	if !ok || move.BoardIndex != 4 || move.Position != 4 {
+		t.Errorf("Expected archived opening E5, got %s", move.ToString())
+	}
+}
+
+func TestBuildStatsSkipsBadGames(t *testing.T) {
+	good := ugn.NewUGNGame("good", "alice", "bob")
+	good.AddMove(ugn.UGNMove{BoardIndex: 4, Position: 4})
+	// The second move is played in the wrong board, after a legal first move
+	// that must not be counted either.
+	bad := ugn.NewUGNGame("bad", "alice", "bob")
+	bad.AddMove(ugn.UGNMove{BoardIndex: 0, Position: 0})
+	bad.AddMove(ugn.UGNMove{BoardIndex: 8, Position: 8})
+
+	stats, err := BuildStats([]*ugn.UGNGame{bad, good}, nil, 1500)
+	if err == nil || !strings.Contains(err.Error(), "bad") {
+		t.Errorf("Expected the bad game to be reported, got %v", err)
+	}
+	if stats == nil || stats.GameCount() != 1 {
+		t.Fatalf("Expected statistics from the good game only")
+	}
+	if counts := stats.PositionCounts(Bucket(1500), game.NewUltimateBoard().Key()); len(counts) != 1 {
+		t.Errorf("Expected only the good game's opening, got %v", counts)
+	}
+}
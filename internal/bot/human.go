@@ -0,0 +1,106 @@
+package bot
+
+import (
+	"math/rand"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+)
+
+// HumanBot imitates players of a target rating by sampling from archived move
+// frequencies. Positions seen fewer than MinSamples times fall back to a
+// shallow search, choosing among moves within Tolerance of the best score
+// weighted by how often players of that rating make moves of the same kind.
+type HumanBot struct {
+	Stats      *MoveStats
+	Rating     int
+	Depth      int
+	MinSamples int
+	Tolerance  int
+	rng        *rand.Rand
+}
+
+func NewHumanBot(stats *MoveStats, rating int, seed int64) *HumanBot {
+	return &HumanBot{
+		Stats:      stats,
+		Rating:     rating,
+		Depth:      2,
+		MinSamples: 3,
+		Tolerance:  60,
+		rng:        rand.New(rand.NewSource(seed)),
+	}
+}
+
+func (hb *HumanBot) ChooseMove(board *game.UltimateBoard) (game.Move, bool) {
+	moves := board.GetValidMoves()
+	if len(moves) == 0 {
+		return game.Move{}, false
+	}
+
+	bucket, ok := hb.Stats.NearestBucket(hb.Rating)
+	if ok {
+		if move, ok := hb.fromPosition(board, bucket); ok {
+			return move, true
+		}
+	}
+
+	candidates := ScoreMoves(board, hb.Depth)
+	best := candidates[0].Score
+	for _, c := range candidates {
+		if c.Score > best {
+			best = c.Score
+		}
+	}
+	weights := make([]int, 0, len(candidates))
+	pool := make([]game.Move, 0, len(candidates))
+	for _, c := range candidates {
+		if c.Score < best-hb.Tolerance {
+			continue
+		}
+		weight := 1
+		if ok {
+			weight += hb.Stats.FeatureCount(bucket, MoveFeature(board, c.Move))
+		}
+		pool = append(pool, c.Move)
+		weights = append(weights, weight)
+	}
+	return pool[hb.sample(weights)], true
+}
+
+func (hb *HumanBot) fromPosition(board *game.UltimateBoard, bucket int) (game.Move, bool) {
+	counts := hb.Stats.PositionCounts(bucket, board.Key())
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	if total < hb.MinSamples {
+		return game.Move{}, false
+	}
+
+	pool := make([]game.Move, 0, len(counts))
+	weights := make([]int, 0, len(counts))
+	for _, move := range board.GetValidMoves() {
+		if n := counts[move]; n > 0 {
+			pool = append(pool, move)
+			weights = append(weights, n)
+		}
+	}
+	if len(pool) == 0 {
+		return game.Move{}, false
+	}
+	return pool[hb.sample(weights)], true
+}
+
+func (hb *HumanBot) sample(weights []int) int {
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+	n := hb.rng.Intn(total)
+	for i, w := range weights {
+		if n < w {
+			return i
+		}
+		n -= w
+	}
+	return len(weights) - 1
+}
@@ -0,0 +1,131 @@
+package bot
+
+import (
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+)
+
+const winScore = 100000
+
+var lines = [8][3]int{
+	{0, 1, 2}, {3, 4, 5}, {6, 7, 8},
+	{0, 3, 6}, {1, 4, 7}, {2, 5, 8},
+	{0, 4, 8}, {2, 4, 6},
+}
+
+var squareWeights = [9]int{3, 2, 3, 2, 4, 2, 3, 2, 3}
+
+// ScoredMove is a legal move with its search score from the mover's point of view.
+type ScoredMove struct {
+	Move  game.Move
+	Score int
+}
+
+// Search runs a fixed-depth negamax with alpha-beta pruning and returns the
+// best move for the side to move. It returns false if there is no legal move.
+func Search(board *game.UltimateBoard, depth int) (game.Move, int, bool) {
+	scored := ScoreMoves(board, depth)
+	if len(scored) == 0 {
+		return game.Move{}, 0, false
+	}
+	best := scored[0]
+	for _, sm := range scored[1:] {
+		if sm.Score > best.Score {
+			best = sm
+		}
+	}
+	return best.Move, best.Score, true
+}
+
+// ScoreMoves searches every legal move to the given depth.
+func ScoreMoves(board *game.UltimateBoard, depth int) []ScoredMove {
+	moves := board.GetValidMoves()
+	scored := make([]ScoredMove, 0, len(moves))
+	for _, move := range moves {
+		child := board.Clone()
+		child.MakeMove(move.BoardIndex, move.Position)
+		score := -negamax(child, depth-1, -winScore-1, winScore+1)
+		scored = append(scored, ScoredMove{Move: move, Score: score})
+	}
+	return scored
+}
+
+func negamax(board *game.UltimateBoard, depth, alpha, beta int) int {
+	if board.State != game.Undecided || depth <= 0 {
+		return Evaluate(board)
+	}
+	for _, move := range board.GetValidMoves() {
+		child := board.Clone()
+		child.MakeMove(move.BoardIndex, move.Position)
+		score := -negamax(child, depth-1, -beta, -alpha)
+		if score > alpha {
+			alpha = score
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+	return alpha
+}
+
+// Evaluate scores a position from the point of view of the side to move.
+func Evaluate(board *game.UltimateBoard) int {
+	me := board.CurrentTurn
+	switch board.State {
+	case game.Draw:
+		return 0
+	case game.XWins:
+		if me == game.X {
+			return winScore
+		}
+		return -winScore
+	case game.OWins:
+		if me == game.O {
+			return winScore
+		}
+		return -winScore
+	}
+
+	var meta [9]game.CellState
+	score := 0
+	for i, sb := range board.Boards {
+		switch sb.State {
+		case game.XWins:
+			meta[i] = game.X
+		case game.OWins:
+			meta[i] = game.O
+		case game.Undecided:
+			score += squareWeights[i] * lineScore(sb.Cells, me) / 4
+			continue
+		}
+		if meta[i] == me {
+			score += 25 * squareWeights[i]
+		} else if meta[i] != game.Empty {
+			score -= 25 * squareWeights[i]
+		}
+	}
+	score += 40 * lineScore(meta, me)
+	return score
+}
+
+// lineScore counts open two-in-a-rows for player minus those of the opponent.
+func lineScore(cells [9]game.CellState, player game.CellState) int {
+	score := 0
+	for _, line := range lines {
+		mine, theirs := 0, 0
+		for _, i := range line {
+			switch cells[i] {
+			case game.Empty:
+			case player:
+				mine++
+			default:
+				theirs++
+			}
+		}
+		if theirs == 0 && mine == 2 {
+			score += 3
+		} else if mine == 0 && theirs == 2 {
+			score -= 3
+		}
+	}
+	return score
+}
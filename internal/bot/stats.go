@@ -0,0 +1,204 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+	"github.com/eshahhh/ultimatetictactoe/internal/ugn"
+)
+
+const BucketWidth = 200
+
+// RatingFunc returns a player's rating, or false when the player is unrated.
+type RatingFunc func(name string) (int, bool)
+
+// MoveStats holds move frequencies gathered from archived games, split into
+// rating buckets. Positions are keyed by UltimateBoard.Key and features by
+// MoveFeature.
+type MoveStats struct {
+	positions map[int]map[string]map[game.Move]int
+	features  map[int]map[string]int
+	games     int
+}
+
+func NewMoveStats() *MoveStats {
+	return &MoveStats{
+		positions: make(map[int]map[string]map[game.Move]int),
+		features:  make(map[int]map[string]int),
+	}
+}
+
+func Bucket(rating int) int {
+	return rating / BucketWidth * BucketWidth
+}
+
+// BuildStats replays each game and records every move under the mover's
+// rating bucket. Unrated players count as defaultRating. Games that do not
+// replay are left out; the statistics of the others are returned together
+// with an error naming them.
+func BuildStats(games []*ugn.UGNGame, ratingOf RatingFunc, defaultRating int) (*MoveStats, error) {
+	stats := NewMoveStats()
+	var errs []string
+	for _, g := range games {
+		if err := stats.AddGame(g, ratingOf, defaultRating); err != nil {
+			errs = append(errs, fmt.Sprintf("game %s: %v", g.Metadata.GameID, err))
+		}
+	}
+	if len(errs) > 0 {
+		return stats, fmt.Errorf("skipped %d game(s): %s", len(errs), strings.Join(errs, "; "))
+	}
+	return stats, nil
+}
+
+// AddGame records the moves of g. Nothing is recorded for a game that does
+// not replay.
+func (s *MoveStats) AddGame(g *ugn.UGNGame, ratingOf RatingFunc, defaultRating int) error {
+	ratingX, ratingO := defaultRating, defaultRating
+	if ratingOf != nil {
+		if r, ok := ratingOf(g.Metadata.PlayerX); ok {
+			ratingX = r
+		}
+		if r, ok := ratingOf(g.Metadata.PlayerO); ok {
+			ratingO = r
+		}
+	}
+	type seen struct {
+		bucket  int
+		key     string
+		move    game.Move
+		feature string
+	}
+	var moves []seen
+	_, err := g.Replay(func(ply int, board *game.UltimateBoard, m ugn.UGNMove) error {
+		bucket := Bucket(ratingX)
+		if board.CurrentTurn == game.O {
+			bucket = Bucket(ratingO)
+		}
+		move := game.Move{BoardIndex: m.BoardIndex, Position: m.Position}
+		moves = append(moves, seen{bucket, board.Key(), move, MoveFeature(board, move)})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, m := range moves {
+		s.record(m.bucket, m.key, m.move, m.feature)
+	}
+	s.games++
+	return nil
+}
+
+func (s *MoveStats) record(bucket int, key string, move game.Move, feature string) {
+	positions := s.positions[bucket]
+	if positions == nil {
+		positions = make(map[string]map[game.Move]int)
+		s.positions[bucket] = positions
+	}
+	counts := positions[key]
+	if counts == nil {
+		counts = make(map[game.Move]int)
+		positions[key] = counts
+	}
+	counts[move]++
+
+	features := s.features[bucket]
+	if features == nil {
+		features = make(map[string]int)
+		s.features[bucket] = features
+	}
+	features[feature]++
+}
+
+func (s *MoveStats) GameCount() int {
+	return s.games
+}
+
+// NearestBucket returns the populated bucket closest to the given rating.
+func (s *MoveStats) NearestBucket(rating int) (int, bool) {
+	target := Bucket(rating)
+	best, found := 0, false
+	for bucket := range s.features {
+		if !found || abs(bucket-target) < abs(best-target) ||
+			(abs(bucket-target) == abs(best-target) && bucket < best) {
+			best, found = bucket, true
+		}
+	}
+	return best, found
+}
+
+func (s *MoveStats) PositionCounts(bucket int, key string) map[game.Move]int {
+	return s.positions[bucket][key]
+}
+
+func (s *MoveStats) FeatureCount(bucket int, feature string) int {
+	return s.features[bucket][feature]
+}
+
+// MoveFeature describes a move independently of the exact position: the cell
+// played, whether the mover had a free choice of board, what the move does to
+// its own board and where it sends the opponent.
+func MoveFeature(board *game.UltimateBoard, move game.Move) string {
+	free := board.ActiveBoard == -1
+	sb := board.Boards[move.BoardIndex]
+	effect := "quiet"
+	switch {
+	case completesLine(sb.Cells, move.Position, board.CurrentTurn):
+		effect = "win"
+	case completesLine(sb.Cells, move.Position, opponent(board.CurrentTurn)):
+		effect = "block"
+	}
+
+	target := move.Position
+	send := "open"
+	switch {
+	case target == move.BoardIndex && effect == "win":
+		send = "free"
+	case board.Boards[target].State != game.Undecided:
+		send = "free"
+	case hasWinningCell(board.Boards[target].Cells, opponent(board.CurrentTurn)):
+		send = "gift"
+	}
+	return fmt.Sprintf("cell=%d free=%t %s send=%s", move.Position, free, effect, send)
+}
+
+func completesLine(cells [9]game.CellState, position int, player game.CellState) bool {
+	for _, line := range lines {
+		count := 0
+		onLine := false
+		for _, i := range line {
+			if i == position {
+				onLine = true
+			} else if cells[i] == player {
+				count++
+			}
+		}
+		if onLine && count == 2 {
+			return true
+		}
+	}
+	return false
+}
+
+func hasWinningCell(cells [9]game.CellState, player game.CellState) bool {
+	for i, cell := range cells {
+		if cell == game.Empty && completesLine(cells, i, player) {
+			return true
+		}
+	}
+	return false
+}
+
+func opponent(player game.CellState) game.CellState {
+	if player == game.X {
+		return game.O
+	}
+	return game.X
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
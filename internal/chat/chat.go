@@ -0,0 +1,206 @@
+// Package chat checks, filters, rate-limits and records in-game chat.
+package chat
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MaxLength = 300 // characters per message
+
+	// A connection may send RateLimit messages per RateWindow.
+	RateLimit  = 5
+	RateWindow = 10 * time.Second
+)
+
+// Message is one line of a game's chat log.
+type Message struct {
+	Time       time.Time `json:"time"`
+	From       string    `json:"from"`
+	Symbol     string    `json:"symbol,omitempty"`
+	Text       string    `json:"text"`
+	Spectators bool      `json:"spectators,omitempty"` // also sent to spectators
+}
+
+// Validate trims a message and checks its length.
+func Validate(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", fmt.Errorf("empty chat message")
+	}
+	if n := utf8.RuneCountInString(text); n > MaxLength {
+		return "", fmt.Errorf("chat message too long (%d characters, at most %d)", n, MaxLength)
+	}
+	return text, nil
+}
+
+// Filter masks banned words. Words match whole, ignoring case.
+type Filter struct {
+	words map[string]bool
+}
+
+func NewFilter(words []string) *Filter {
+	f := &Filter{words: make(map[string]bool)}
+	for _, w := range words {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			f.words[w] = true
+		}
+	}
+	return f
+}
+
+// LoadFilter reads a word list with one word per line. Blank lines and lines
+// starting with # are ignored.
+func LoadFilter(path string) (*Filter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open word filter: %v", err)
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			words = append(words, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read word filter: %v", err)
+	}
+	return NewFilter(words), nil
+}
+
+func (f *Filter) Len() int {
+	return len(f.words)
+}
+
+// Clean replaces every banned word in text with asterisks.
+func (f *Filter) Clean(text string) string {
+	if f == nil || len(f.words) == 0 {
+		return text
+	}
+	var sb strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			sb.WriteRune(runes[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && isWordRune(runes[j]) {
+			j++
+		}
+		word := string(runes[i:j])
+		if f.words[strings.ToLower(word)] {
+			sb.WriteString(strings.Repeat("*", j-i))
+		} else {
+			sb.WriteString(word)
+		}
+		i = j
+	}
+	return sb.String()
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\''
+}
+
+// Limiter allows at most Max events in any Window.
+type Limiter struct {
+	Max    int
+	Window time.Duration
+	sent   []time.Time
+	mutex  sync.Mutex
+}
+
+func NewLimiter(max int, window time.Duration) *Limiter {
+	return &Limiter{Max: max, Window: window}
+}
+
+// Allow records an event at now unless that would exceed the limit.
+func (l *Limiter) Allow(now time.Time) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	recent := l.sent[:0]
+	for _, t := range l.sent {
+		if now.Sub(t) < l.Window {
+			recent = append(recent, t)
+		}
+	}
+	l.sent = recent
+	if len(l.sent) >= l.Max {
+		return false
+	}
+	l.sent = append(l.sent, now)
+	return true
+}
+
+// LogPath is where the chat of the game recorded at recordPath is kept:
+// next to it, with .chat in place of .ugn.
+func LogPath(recordPath string) string {
+	return strings.TrimSuffix(recordPath, ".ugn") + ".chat"
+}
+
+// Log appends a game's messages to its chat file, one JSON object per line.
+type Log struct {
+	path  string
+	mutex sync.Mutex
+}
+
+func NewLog(path string) *Log {
+	return &Log{path: path}
+}
+
+func (l *Log) Append(m Message) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open chat log: %v", err)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write chat log: %v", err)
+	}
+	return file.Close()
+}
+
+// Read returns the messages in a chat file.
+func Read(path string) ([]Message, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open chat log: %v", err)
+	}
+	defer file.Close()
+
+	var messages []Message
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var m Message
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		messages = append(messages, m)
+	}
+	return messages, scanner.Err()
+}
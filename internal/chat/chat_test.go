@@ -0,0 +1,72 @@
+package chat
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	if text, err := Validate("  good game  "); err != nil || text != "good game" {
+		t.Errorf("Validate = %q, %v", text, err)
+	}
+	if _, err := Validate("   "); err == nil {
+		t.Error("Expected an error for an empty message")
+	}
+	if _, err := Validate(strings.Repeat("é", MaxLength+1)); err == nil {
+		t.Error("Expected an error for a long message")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	f := NewFilter([]string{"darn", " Heck "})
+	tests := map[string]string{
+		"darn it":           "**** it",
+		"HECK, that hurt":   "****, that hurt",
+		"darned good move":  "darned good move",
+		"nothing to see":    "nothing to see",
+		"heck! darn! heck!": "****! ****! ****!",
+	}
+	for input, want := range tests {
+		if got := f.Clean(input); got != want {
+			t.Errorf("Clean(%q) = %q, want %q", input, got, want)
+		}
+	}
+	var none *Filter
+	if got := none.Clean("darn"); got != "darn" {
+		t.Errorf("A nil filter changed the text to %q", got)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter(2, time.Second)
+	now := time.Now()
+	if !l.Allow(now) || !l.Allow(now.Add(100*time.Millisecond)) {
+		t.Fatal("Expected the first two messages to pass")
+	}
+	if l.Allow(now.Add(500 * time.Millisecond)) {
+		t.Error("Expected the third message within the window to be refused")
+	}
+	if !l.Allow(now.Add(1100 * time.Millisecond)) {
+		t.Error("Expected a message once the window has moved on")
+	}
+}
+
+func TestLog(t *testing.T) {
+	path := LogPath(filepath.Join(t.TempDir(), "20260101_120000_ABC.ugn"))
+	if !strings.HasSuffix(path, "20260101_120000_ABC.chat") {
+		t.Fatalf("Unexpected log path %s", path)
+	}
+	l := NewLog(path)
+	l.Append(Message{From: "alice", Symbol: "X", Text: "good luck"})
+	l.Append(Message{From: "bob", Symbol: "O", Text: "you too", Spectators: true})
+
+	messages, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(messages) != 2 || messages[1].From != "bob" || !messages[1].Spectators {
+		t.Errorf("Unexpected messages %+v", messages)
+	}
+}
@@ -0,0 +1,247 @@
+// Package explorer reports which moves were played from a position in the
+// archived games and how those games ended.
+package explorer
+
+import (
+	"container/list"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/archive"
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+	"github.com/eshahhh/ultimatetictactoe/internal/rating"
+	"github.com/eshahhh/ultimatetictactoe/internal/ugn"
+)
+
+// Filter restricts the games counted. Ratings are the average of both
+// players' current ratings; zero bounds and empty dates are ignored.
+type Filter struct {
+	MinRating int
+	MaxRating int
+	Since     string // YYYY-MM-DD, inclusive
+	Until     string // YYYY-MM-DD, inclusive
+}
+
+// Tally counts finished games and their results from X's point of view.
+type Tally struct {
+	Games       int     `json:"games"`
+	XWins       int     `json:"x_wins"`
+	OWins       int     `json:"o_wins"`
+	Draws       int     `json:"draws"`
+	XPercent    float64 `json:"x_percent"`
+	OPercent    float64 `json:"o_percent"`
+	DrawPercent float64 `json:"draw_percent"`
+}
+
+func (t *Tally) add(result string) {
+	t.Games++
+	switch result {
+	case "X":
+		t.XWins++
+	case "O":
+		t.OWins++
+	default:
+		t.Draws++
+	}
+}
+
+func (t *Tally) finish() {
+	if t.Games == 0 {
+		return
+	}
+	percent := func(n int) float64 {
+		return float64(n*1000/t.Games) / 10
+	}
+	t.XPercent, t.OPercent, t.DrawPercent = percent(t.XWins), percent(t.OWins), percent(t.Draws)
+}
+
+type MoveStats struct {
+	Move string `json:"move"`
+	Tally
+}
+
+// Report describes the position reached by Moves: every finished game that
+// passed through it, and the moves played next, most popular first.
+type Report struct {
+	Moves []string `json:"moves"`
+	Tally
+	Next []MoveStats `json:"next"`
+}
+
+// DefaultMaxCached is how many replayed games an explorer keeps by default.
+const DefaultMaxCached = 10000
+
+type gameRecord struct {
+	file    string
+	element *list.Element // position in Explorer.recent
+	modTime time.Time
+	size    int64
+	moves   []game.Move
+	reached map[uint64]int // position hash -> ply at which it was reached
+}
+
+// Explorer answers position queries over an archive index. Games are read
+// once and cached until their file changes; callers refresh the index.
+// Once MaxCached games are cached the least recently used one is dropped.
+type Explorer struct {
+	MaxCached int
+
+	index   *archive.Index
+	ratings *rating.Table
+	games   map[string]*gameRecord
+	recent  *list.List // of *gameRecord, most recently used first
+	mutex   sync.Mutex
+}
+
+// New creates an explorer. ratings may be nil, in which case every player
+// counts as rating.DefaultRating.
+func New(index *archive.Index, ratings *rating.Table) *Explorer {
+	return &Explorer{
+		MaxCached: DefaultMaxCached,
+		index:     index,
+		ratings:   ratings,
+		games:     make(map[string]*gameRecord),
+		recent:    list.New(),
+	}
+}
+
+func (ex *Explorer) Explore(prefix []game.Move, f Filter) (*Report, error) {
+	board := game.NewUltimateBoard()
+	report := &Report{Moves: make([]string, len(prefix)), Next: []MoveStats{}}
+	for i := range prefix {
+		if err := board.MakeMove(prefix[i].BoardIndex, prefix[i].Position); err != nil {
+			return nil, fmt.Errorf("move %d (%s): %v", i+1, prefix[i].ToString(), err)
+		}
+		report.Moves[i] = prefix[i].ToString()
+	}
+	hash := board.Hash()
+
+	entries := ex.index.Search(archive.Query{Position: prefix, Since: f.Since, Until: f.Until})
+	next := make(map[game.Move]*MoveStats)
+	for _, e := range entries {
+		if e.Result != "X" && e.Result != "O" && e.Result != "Draw" {
+			continue
+		}
+		if !ex.ratingMatches(e, f) {
+			continue
+		}
+		record, err := ex.load(e)
+		if err != nil {
+			continue
+		}
+		ply, ok := record.reached[hash]
+		if !ok {
+			continue
+		}
+		report.add(e.Result)
+		if ply < len(record.moves) {
+			move := record.moves[ply]
+			stats, ok := next[move]
+			if !ok {
+				stats = &MoveStats{Move: move.ToString()}
+				next[move] = stats
+			}
+			stats.add(e.Result)
+		}
+	}
+
+	report.finish()
+	for _, stats := range next {
+		stats.finish()
+		report.Next = append(report.Next, *stats)
+	}
+	sort.Slice(report.Next, func(i, j int) bool {
+		if report.Next[i].Games != report.Next[j].Games {
+			return report.Next[i].Games > report.Next[j].Games
+		}
+		return report.Next[i].Move < report.Next[j].Move
+	})
+	return report, nil
+}
+
+func (ex *Explorer) ratingMatches(e *archive.Entry, f Filter) bool {
+	if f.MinRating == 0 && f.MaxRating == 0 {
+		return true
+	}
+	ratingX, ratingO := rating.DefaultRating, rating.DefaultRating
+	if ex.ratings != nil {
+		ratingX, ratingO = ex.ratings.Get(e.PlayerX), ex.ratings.Get(e.PlayerO)
+	}
+	average := (ratingX + ratingO) / 2
+	if f.MinRating != 0 && average < f.MinRating {
+		return false
+	}
+	if f.MaxRating != 0 && average > f.MaxRating {
+		return false
+	}
+	return true
+}
+
+func (ex *Explorer) load(e *archive.Entry) (*gameRecord, error) {
+	ex.mutex.Lock()
+	defer ex.mutex.Unlock()
+
+	if record, ok := ex.games[e.File]; ok {
+		if record.size == e.Size && record.modTime.Equal(e.ModTime) {
+			ex.recent.MoveToFront(record.element)
+			return record, nil
+		}
+		ex.forget(record)
+	}
+	g, err := ugn.ParseUGNFile(ex.index.Path(e))
+	if err != nil {
+		return nil, err
+	}
+	record := &gameRecord{
+		file:    e.File,
+		modTime: e.ModTime,
+		size:    e.Size,
+		moves:   make([]game.Move, 0, len(g.Moves)),
+		reached: make(map[uint64]int),
+	}
+	board, err := g.Replay(func(ply int, board *game.UltimateBoard, move ugn.UGNMove) error {
+		record.reached[board.Hash()] = ply
+		record.moves = append(record.moves, game.Move{BoardIndex: move.BoardIndex, Position: move.Position})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	record.reached[board.Hash()] = len(record.moves)
+	ex.games[e.File] = record
+	record.element = ex.recent.PushFront(record)
+	for ex.recent.Len() > max(ex.MaxCached, 1) {
+		ex.forget(ex.recent.Back().Value.(*gameRecord))
+	}
+	return record, nil
+}
+
+// forget drops a game from the cache. The caller holds the lock.
+func (ex *Explorer) forget(record *gameRecord) {
+	ex.recent.Remove(record.element)
+	delete(ex.games, record.file)
+}
+
+// ParseRequest reads the move prefix and filter from URL parameters: moves
+// (e.g. "E5,E1"), min_rating, max_rating, since and until.
+func ParseRequest(values url.Values) ([]game.Move, Filter, error) {
+	f := Filter{Since: values.Get("since"), Until: values.Get("until")}
+	prefix, err := archive.ParseMoveList(values.Get("moves"))
+	if err != nil {
+		return nil, f, fmt.Errorf("invalid moves: %v", err)
+	}
+	for name, bound := range map[string]*int{"min_rating": &f.MinRating, "max_rating": &f.MaxRating} {
+		if s := values.Get(name); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 0 {
+				return nil, f, fmt.Errorf("invalid %s %q", name, s)
+			}
+			*bound = n
+		}
+	}
+	return prefix, f, nil
+}
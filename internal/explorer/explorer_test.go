@@ -0,0 +1,99 @@
+package explorer
+
+import (
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/archive"
+	"github.com/eshahhh/ultimatetictactoe/internal/rating"
+	"github.com/eshahhh/ultimatetictactoe/internal/ugn"
+)
+
+func writeGame(t *testing.T, dir, id, playerX, playerO, result, moves string) {
+	t.Helper()
+	parsed, err := archive.ParseMoveList(moves)
+	if err != nil {
+		t.Fatalf("Bad moves %q: %v", moves, err)
+	}
+	g, err := ugn.NewGameFromMoves(id, playerX, playerO, parsed)
+	if err != nil {
+		t.Fatalf("Bad game %s: %v", id, err)
+	}
+	g.SetResult(result)
+	if err := g.WriteUGNFile(filepath.Join(dir, id+".ugn")); err != nil {
+		t.Fatalf("Failed to write %s: %v", id, err)
+	}
+}
+
+func TestExplore(t *testing.T) {
+	dir := t.TempDir()
+	writeGame(t, dir, "g1", "alice", "bob", "X", "E5 E1 A5")
+	writeGame(t, dir, "g2", "bob", "alice", "O", "E5 E1 A9")
+	writeGame(t, dir, "g3", "carol", "dave", "Draw", "E5 E1 A5 E9")
+	writeGame(t, dir, "g4", "carol", "dave", "X", "A5 E1")
+	writeGame(t, dir, "g5", "carol", "dave", "*", "E5 E1")
+
+	ix, err := archive.Open(dir)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if _, err := ix.Update(); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	ratings := rating.NewTable()
+	ratings.Set("carol", 1900)
+	ratings.Set("dave", 1900)
+	ex := New(ix, ratings)
+
+	prefix, f, err := ParseRequest(url.Values{"moves": {"E5,E1"}})
+	if err != nil {
+		t.Fatalf("ParseRequest failed: %v", err)
+	}
+	report, err := ex.Explore(prefix, f)
+	if err != nil {
+		t.Fatalf("Explore failed: %v", err)
+	}
+	if report.Games != 3 || report.XWins != 1 || report.OWins != 1 || report.Draws != 1 {
+		t.Errorf("Unexpected totals: %+v", report.Tally)
+	}
+	if len(report.Next) != 2 || report.Next[0].Move != "A5" || report.Next[0].Games != 2 {
+		t.Fatalf("Unexpected next moves: %+v", report.Next)
+	}
+	if report.Next[0].XPercent != 50 || report.Next[0].DrawPercent != 50 {
+		t.Errorf("Unexpected A5 percentages: %+v", report.Next[0])
+	}
+
+	report, err = ex.Explore(prefix, Filter{MinRating: 1800})
+	if err != nil {
+		t.Fatalf("Explore failed: %v", err)
+	}
+	if report.Games != 1 || len(report.Next) != 1 || report.Next[0].Draws != 1 {
+		t.Errorf("Rating filter not applied: %+v", report)
+	}
+
+	// A cache smaller than the archive gives the same answers.
+	small := New(ix, ratings)
+	small.MaxCached = 2
+	if again, err := small.Explore(prefix, Filter{}); err != nil || again.Games != 3 || again.Next[0].Games != 2 {
+		t.Errorf("Unexpected report with a small cache: %+v (%v)", again, err)
+	}
+	if len(small.games) > 2 || small.recent.Len() != len(small.games) {
+		t.Errorf("Expected at most 2 cached games, have %d", len(small.games))
+	}
+
+	report, err = ex.Explore(nil, Filter{})
+	if err != nil {
+		t.Fatalf("Explore failed: %v", err)
+	}
+	if report.Games != 4 || report.Next[0].Move != "E5" || report.Next[0].Games != 3 {
+		t.Errorf("Unexpected start position report: %+v", report)
+	}
+
+	if _, err := ex.Explore(prefix[:1:1], Filter{}); err != nil {
+		t.Errorf("Explore of a one-move prefix failed: %v", err)
+	}
+	if _, _, err := ParseRequest(url.Values{"min_rating": {"high"}}); err == nil {
+		t.Error("Expected an error for a bad rating")
+	}
+}
@@ -2,6 +2,7 @@ package game
 
 import (
 	"fmt"
+	"hash/fnv"
 	"strings"
 )
 
@@ -269,6 +270,11 @@ func (ub *UltimateBoard) GetAvailableBoards() []int {
 }
 
 func (ub *UltimateBoard) GetBoardDisplay() string {
+	return ub.String() + "\nEnter your move (e.g., A5, D2, I9): "
+}
+
+// String draws the board, board states and move legend as text.
+func (ub *UltimateBoard) String() string {
 	var result strings.Builder
 	result.WriteString(fmt.Sprintf("Current turn: %s\n", ub.CurrentTurn))
 	if ub.ActiveBoard != -1 {
@@ -355,7 +361,6 @@ func (ub *UltimateBoard) GetBoardDisplay() string {
 	result.WriteString("   4 | 5 | 6       E5 = Board E, cell 5 (center)\n")
 	result.WriteString("   ---------       I9 = Board I, cell 9 (bottom-right)\n")
 	result.WriteString("   7 | 8 | 9\n")
-	result.WriteString("\nEnter your move (e.g., A5, D2, I9): ")
 	return result.String()
 }
 
@@ -371,3 +376,61 @@ func (ub *UltimateBoard) getGameStateString() string {
 		return "In Progress"
 	}
 }
+
+func (ub *UltimateBoard) Clone() *UltimateBoard {
+	clone := &UltimateBoard{
+		State:       ub.State,
+		ActiveBoard: ub.ActiveBoard,
+		CurrentTurn: ub.CurrentTurn,
+	}
+	for i, board := range ub.Boards {
+		sb := *board
+		clone.Boards[i] = &sb
+	}
+	return clone
+}
+
+func (ub *UltimateBoard) GetValidMoves() []Move {
+	moves := []Move{}
+	for _, boardIndex := range ub.GetAvailableBoards() {
+		for position := 0; position < 9; position++ {
+			if ub.IsValidMove(boardIndex, position) {
+				moves = append(moves, Move{BoardIndex: boardIndex, Position: position})
+			}
+		}
+	}
+	return moves
+}
+
+// Key identifies a position: 81 cells in board order followed by the active
+// board ('-' for any) and the side to move.
+func (ub *UltimateBoard) Key() string {
+	var key strings.Builder
+	key.Grow(83)
+	for _, board := range ub.Boards {
+		for _, cell := range board.Cells {
+			switch cell {
+			case X:
+				key.WriteByte('x')
+			case O:
+				key.WriteByte('o')
+			default:
+				key.WriteByte('.')
+			}
+		}
+	}
+	if ub.ActiveBoard == -1 {
+		key.WriteByte('-')
+	} else {
+		key.WriteByte(byte('A' + ub.ActiveBoard))
+	}
+	key.WriteString(ub.CurrentTurn.String())
+	return key.String()
+}
+
+// Hash is a 64-bit FNV-1a hash of Key, used to index positions.
+func (ub *UltimateBoard) Hash() uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(ub.Key()))
+	return h.Sum64()
+}
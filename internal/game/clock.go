@@ -0,0 +1,75 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeControl gives each player Base time for the game plus a per-move
+// bonus. By default the bonus is a Fischer increment added after every
+// move; with Delay set the clock instead only starts running once the
+// bonus has passed, and unused delay is lost. The zero value is untimed.
+type TimeControl struct {
+	Base      time.Duration
+	Increment time.Duration
+	Delay     bool
+}
+
+// ParseTimeControl reads the UGN form: seconds of base time, then "+" and
+// the increment or "d" and the delay, e.g. "300+2" or "180d3". "" and "-"
+// mean untimed. A space is read as "+", since that is what an unescaped "+"
+// in a query string decodes to.
+func ParseTimeControl(s string) (TimeControl, error) {
+	s = strings.Replace(strings.TrimSpace(s), " ", "+", 1)
+	if s == "" || s == "-" {
+		return TimeControl{}, nil
+	}
+
+	var tc TimeControl
+	base, bonus, found := strings.Cut(s, "+")
+	if !found {
+		base, bonus, found = strings.Cut(s, "d")
+		tc.Delay = found
+	}
+	seconds, err := strconv.Atoi(base)
+	if err != nil || seconds <= 0 {
+		return TimeControl{}, fmt.Errorf("invalid time control %q: base must be a positive number of seconds", s)
+	}
+	tc.Base = time.Duration(seconds) * time.Second
+	if found {
+		seconds, err := strconv.Atoi(bonus)
+		if err != nil || seconds < 0 {
+			return TimeControl{}, fmt.Errorf("invalid time control %q: bad increment", s)
+		}
+		tc.Increment = time.Duration(seconds) * time.Second
+	}
+	return tc, nil
+}
+
+func (tc TimeControl) Timed() bool {
+	return tc.Base > 0
+}
+
+func (tc TimeControl) String() string {
+	if !tc.Timed() {
+		return "-"
+	}
+	sep := "+"
+	if tc.Delay {
+		sep = "d"
+	}
+	return fmt.Sprintf("%d%s%d", int(tc.Base/time.Second), sep, int(tc.Increment/time.Second))
+}
+
+// charge is how much of a player's clock thinking for elapsed costs.
+func (tc TimeControl) charge(elapsed time.Duration) time.Duration {
+	if tc.Delay {
+		if elapsed <= tc.Increment {
+			return 0
+		}
+		return elapsed - tc.Increment
+	}
+	return elapsed
+}
@@ -1,7 +1,11 @@
 package game
 
 import (
+	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseMove(t *testing.T) {
@@ -145,3 +149,448 @@ func TestValidateMoveFormat(t *testing.T) {
 		}
 	}
 }
+
+func TestUltimateBoardClone(t *testing.T) {
+	board := NewUltimateBoard()
+	board.MakeMove(4, 4)
+
+	clone := board.Clone()
+	clone.MakeMove(4, 0)
+
+	if board.Boards[4].Cells[0] != Empty {
+		t.Errorf("Move on clone should not affect the original board")
+	}
+	if board.Key() == clone.Key() {
+		t.Errorf("Expected different keys after diverging, got %s", board.Key())
+	}
+}
+
+func TestGetValidMoves(t *testing.T) {
+	board := NewUltimateBoard()
+
+	if moves := board.GetValidMoves(); len(moves) != 81 {
+		t.Errorf("Expected 81 moves on an empty board, got %d", len(moves))
+	}
+
+	board.MakeMove(0, 4)
+	moves := board.GetValidMoves()
+	if len(moves) != 9 {
+		t.Errorf("Expected 9 moves on board E, got %d", len(moves))
+	}
+	for _, move := range moves {
+		if move.BoardIndex != 4 {
+			t.Errorf("Expected all moves on board 4, got %+v", move)
+		}
+	}
+}
+
+func TestPuzzleSession(t *testing.T) {
+	setup := []Move{{BoardIndex: 4, Position: 4}}
+	solution := []Move{{BoardIndex: 4, Position: 0}, {BoardIndex: 0, Position: 4}, {BoardIndex: 4, Position: 8}}
+
+	ps, err := NewPuzzleSession("p1", "puzzle", 1200, setup, solution, nil, "alice")
+	if err != nil {
+		t.Fatalf("NewPuzzleSession failed: %v", err)
+	}
+	if ps.Player.Symbol != O || ps.MovesToFind() != 2 {
+		t.Fatalf("Expected O to find 2 moves, got %s and %d", ps.Player.Symbol, ps.MovesToFind())
+	}
+
+	if _, err := ps.MakeMove(&Move{BoardIndex: 0, Position: 0}); err == nil {
+		t.Error("Expected an error for a move outside the active board")
+	}
+	reply, err := ps.MakeMove(&Move{BoardIndex: 4, Position: 0})
+	if err != nil || reply == nil || *reply != solution[1] {
+		t.Fatalf("Expected reply A5, got %v (%v)", reply, err)
+	}
+	if _, err := ps.MakeMove(&Move{BoardIndex: 4, Position: 8}); err != nil {
+		t.Fatalf("MakeMove failed: %v", err)
+	}
+	if !ps.Finished || !ps.Solved {
+		t.Errorf("Expected the puzzle to be solved")
+	}
+	if state := ps.GetGameState(); len(state.UGNMoves) != 4 || state.GameStatus != "finished" {
+		t.Errorf("Unexpected state: %+v", state)
+	}
+
+	ps, _ = NewPuzzleSession("p2", "puzzle", 1200, setup, solution, nil, "alice")
+	if reply, err := ps.MakeMove(&Move{BoardIndex: 4, Position: 1}); err != nil || reply != nil {
+		t.Fatalf("Expected a wrong move to end the puzzle, got %v (%v)", reply, err)
+	}
+	if !ps.Finished || ps.Solved {
+		t.Errorf("Expected the puzzle to be failed")
+	}
+}
+
+func TestReconnectPlayer(t *testing.T) {
+	session := NewGameSessionWithPlayers("g1", nil, "alice", nil, "bob")
+	player := session.Players[0]
+
+	session.DisconnectPlayer(player)
+	if player.IsConnected() || session.Players[0] != player {
+		t.Fatalf("Expected the disconnected player to keep the seat")
+	}
+	session.BroadcastToAll("still safe without a connection")
+
+	if err := session.ReconnectPlayer(player, nil); err != nil {
+		t.Fatalf("ReconnectPlayer failed: %v", err)
+	}
+	session.ReleasePlayer(player)
+	if session.Players[0] != nil {
+		t.Fatalf("Expected ReleasePlayer to free the seat")
+	}
+	if err := session.ReconnectPlayer(player, nil); err == nil {
+		t.Error("Expected an error reconnecting to a released seat")
+	}
+}
+
+func TestAbandonGame(t *testing.T) {
+	session := NewGameSessionWithPlayers("g1", nil, "alice", nil, "bob")
+	x, o := session.Players[0], session.Players[1]
+	if x.Symbol != X {
+		x, o = o, x
+	}
+
+	aborted, err := session.AbandonGame(o)
+	if err != nil || !aborted || session.Winner != Empty || session.GetGameStateForPlayer(o).GameStatus != "aborted" {
+		t.Fatalf("Expected an early abandonment to abort the game, got aborted=%v winner=%s (%v)", aborted, session.Winner, err)
+	}
+	if _, err := session.AbandonGame(x); err == nil {
+		t.Error("Expected an error abandoning a finished game")
+	}
+
+	session = NewGameSessionWithPlayers("g2", nil, "alice", nil, "bob")
+	x, o = session.Players[0], session.Players[1]
+	if x.Symbol != X {
+		x, o = o, x
+	}
+	session.MakeMove(x, &Move{BoardIndex: 4, Position: 4})
+	session.MakeMove(o, &Move{BoardIndex: 4, Position: 0})
+	aborted, err = session.AbandonGame(o)
+	if err != nil || aborted || session.Winner != X {
+		t.Fatalf("Expected O to forfeit, got aborted=%v winner=%s (%v)", aborted, session.Winner, err)
+	}
+}
+
+func TestSpectators(t *testing.T) {
+	session := NewGameSessionWithPlayers("g1", nil, "alice", nil, "bob")
+	carol := session.AddSpectator(nil, "carol")
+	session.AddSpectator(nil, "dave")
+
+	state := session.GetGameStateForPlayer(nil)
+	if state.Spectators != 2 || state.YourSymbol != "" || state.IsYourTurn {
+		t.Fatalf("Unexpected spectator view: %+v", state)
+	}
+	session.RemoveSpectator(carol)
+	if spectators := session.Spectators(); len(spectators) != 1 || spectators[0].Name != "dave" {
+		t.Errorf("Expected only dave to be left, got %v", spectators)
+	}
+	if data, _ := json.Marshal(state); strings.Contains(string(data), "your_symbol") {
+		t.Errorf("Expected the spectator view to leave out your_symbol: %s", data)
+	}
+
+	session.ReleasePlayer(session.Players[0])
+	if players := session.PlayerList(); len(players) != 1 || players[0].Name != "bob" {
+		t.Errorf("Expected only bob to be seated, got %+v", players)
+	}
+}
+
+func TestRooms(t *testing.T) {
+	if _, err := ParseRoomSettings("", "purple", "", ""); err == nil {
+		t.Error("Expected an error for an unknown colour")
+	}
+	if _, err := ParseRoomSettings("", "", "misere", ""); err == nil {
+		t.Error("Expected an error for an unsupported variant")
+	}
+	if _, err := ParseRoomSettings("", "", "", "maybe"); err == nil {
+		t.Error("Expected an error for an invalid rated setting")
+	}
+	settings, err := ParseRoomSettings("300+2", "O", "", "1")
+	if err != nil || settings.HostColor != O || settings.Variant != VariantStandard || !settings.Rated {
+		t.Fatalf("Unexpected settings %+v (%v)", settings, err)
+	}
+
+	gm := NewGameManager()
+	room := gm.CreateRoom(nil, "alice", settings)
+	if len(room.Code) != 6 || gm.GetRoom(strings.ToLower(room.Code)) != room {
+		t.Fatalf("Expected to find room %q by code", room.Code)
+	}
+
+	session, joined, err := gm.JoinRoom(strings.ToLower(room.Code), nil, "bob")
+	if err != nil || joined != room {
+		t.Fatalf("JoinRoom failed: %v", err)
+	}
+	if session.Players[0].Name != "alice" || session.Players[0].Symbol != O || session.Players[1].Symbol != X || !session.Started {
+		t.Errorf("Unexpected players %+v %+v", session.Players[0], session.Players[1])
+	}
+	if gm.GetSession(session.ID) != session || gm.GetRoom(room.Code) != nil {
+		t.Error("Expected the room to turn into a managed session")
+	}
+	if !session.Rated {
+		t.Error("Expected the game of a rated room to be rated")
+	}
+	if _, _, err := gm.JoinRoom(room.Code, nil, "carol"); err == nil {
+		t.Error("Expected an error joining a room that already started")
+	}
+}
+
+func TestRematch(t *testing.T) {
+	gm := NewGameManager()
+	session := NewGameSessionWithPlayers("g1", nil, "alice", nil, "bob")
+	gm.AddSession(session)
+	alice, bob := session.Players[0], session.Players[1]
+	carol := session.AddSpectator(nil, "carol")
+
+	if err := session.OfferRematch(alice); err == nil {
+		t.Fatal("Expected an error offering a rematch before the game ends")
+	}
+	session.ResignGame(bob)
+	if err := session.OfferRematch(alice); err != nil {
+		t.Fatalf("OfferRematch failed: %v", err)
+	}
+	if _, err := gm.StartRematch(session, alice); err == nil {
+		t.Fatal("Expected an error accepting your own rematch offer")
+	}
+
+	next, err := gm.StartRematch(session, bob)
+	if err != nil {
+		t.Fatalf("StartRematch failed: %v", err)
+	}
+	nextAlice := session.Counterpart(alice, next)
+	if nextAlice.Name != "alice" || nextAlice.Symbol == alice.Symbol {
+		t.Errorf("Expected alice to swap symbols, got %+v", nextAlice)
+	}
+	if next.Round != 2 || next.PreviousGame != "g1" || next.MatchScore != (MatchScore{1, 0}) || gm.GetSession(next.ID) != next {
+		t.Errorf("Unexpected rematch: round %d previous %q score %v", next.Round, next.PreviousGame, next.MatchScore)
+	}
+	if session.SpectatorCount() != 0 || next.SpectatorCount() != 1 || session.Latest() != next {
+		t.Errorf("Expected carol to follow the players to the rematch")
+	}
+	session.RemoveSpectator(carol)
+	if next.SpectatorCount() != 0 {
+		t.Error("Expected carol to be removed from the rematch")
+	}
+
+	next.OfferDraw(nextAlice)
+	next.AcceptDraw()
+	if score := next.Score(); score != (MatchScore{1.5, 0.5}) {
+		t.Errorf("Expected a 1.5-0.5 score, got %v", score)
+	}
+	if match := next.GetGameStateForPlayer(nil).Match; match == nil || match.Round != 2 {
+		t.Errorf("Unexpected match payload %+v", match)
+	}
+}
+
+func TestTakeback(t *testing.T) {
+	session := NewGameSessionWithPlayers("g1", nil, "alice", nil, "bob")
+	x, o := session.GetCurrentPlayer(), session.GetOpponent(session.GetCurrentPlayer())
+
+	if _, err := session.RequestTakeback(x); err == nil {
+		t.Fatal("Expected an error taking back before any move")
+	}
+	session.MakeMove(x, &Move{BoardIndex: 4, Position: 4})
+	session.MakeMove(o, &Move{BoardIndex: 4, Position: 0})
+	if _, err := session.RequestTakeback(x); err == nil {
+		t.Fatal("Expected an error taking back the opponent's move")
+	}
+
+	move, err := session.RequestTakeback(o)
+	if err != nil || *move != (Move{BoardIndex: 4, Position: 0}) {
+		t.Fatalf("RequestTakeback = %v, %v", move, err)
+	}
+	if _, err := session.AcceptTakeback(o); err == nil {
+		t.Fatal("Expected an error accepting your own takeback request")
+	}
+	if _, err := session.AcceptTakeback(x); err != nil {
+		t.Fatalf("AcceptTakeback failed: %v", err)
+	}
+	board, moves := session.Snapshot()
+	if len(moves) != 1 || board.CurrentTurn != O || board.ActiveBoard != 4 || board.Boards[4].Cells[0] != Empty {
+		t.Errorf("Unexpected position after takeback: %d moves, %s to move in board %d", len(moves), board.CurrentTurn, board.ActiveBoard)
+	}
+
+	// A move cancels a pending request.
+	session.MakeMove(o, &Move{BoardIndex: 4, Position: 0})
+	session.RequestTakeback(o)
+	session.MakeMove(x, &Move{BoardIndex: 0, Position: 4})
+	if _, err := session.AcceptTakeback(x); err == nil {
+		t.Error("Expected the request to lapse once the opponent moved")
+	}
+
+	session.MakeMove(o, &Move{BoardIndex: 4, Position: 8})
+	session.RequestTakeback(o)
+	session.AcceptTakeback(x)
+	if left := session.TakebacksLeft(); left != 0 {
+		t.Errorf("Expected no takebacks left, got %d", left)
+	}
+	if _, err := session.RequestTakeback(x); err == nil {
+		t.Error("Expected the limit to be shared by both players")
+	}
+	session.MakeMove(o, &Move{BoardIndex: 4, Position: 8})
+	if _, err := session.RequestTakeback(o); err == nil {
+		t.Error("Expected an error past the takeback limit")
+	}
+
+	rated := NewGameSessionWithPlayers("g2", nil, "alice", nil, "bob")
+	rated.Rated = true
+	first := rated.GetCurrentPlayer()
+	rated.MakeMove(first, &Move{BoardIndex: 4, Position: 4})
+	if _, err := rated.RequestTakeback(first); err == nil {
+		t.Error("Expected takebacks to be refused in a rated game")
+	}
+}
+
+func TestTakebackClock(t *testing.T) {
+	session := NewGameSessionWithPlayers("g1", nil, "alice", nil, "bob")
+	tc, _ := ParseTimeControl("60+5")
+	session.SetTimeControl(tc)
+	x, o := session.GetCurrentPlayer(), session.GetOpponent(session.GetCurrentPlayer())
+	session.MakeMove(x, &Move{BoardIndex: 4, Position: 4})
+	session.MakeMove(o, &Move{BoardIndex: 4, Position: 0})
+	session.RequestTakeback(o)
+
+	// X thought for 10s before accepting, and O has less left than the
+	// increment they are about to give back.
+	session.lastMoveAt = time.Now().Add(-10 * time.Second)
+	o.Remaining = 2 * time.Second
+	before := x.Remaining
+	if _, err := session.AcceptTakeback(x); err != nil {
+		t.Fatalf("AcceptTakeback failed: %v", err)
+	}
+	if spent := before - x.Remaining; spent < 10*time.Second || spent > 11*time.Second {
+		t.Errorf("Expected X to be charged about 10s, got %s", spent)
+	}
+	if o.Remaining != 0 {
+		t.Errorf("Expected O's clock to stop at 0, got %s", o.Remaining)
+	}
+
+	slow := NewGameSessionWithPlayers("g2", nil, "alice", nil, "bob")
+	slow.SetTimeControl(tc)
+	x, o = slow.GetCurrentPlayer(), slow.GetOpponent(slow.GetCurrentPlayer())
+	slow.MakeMove(x, &Move{BoardIndex: 4, Position: 4})
+	slow.RequestTakeback(x)
+	slow.lastMoveAt = time.Now().Add(-2 * time.Minute)
+	if _, err := slow.AcceptTakeback(o); !errors.Is(err, ErrTimeUp) || !slow.IsFinished() || slow.Winner != X {
+		t.Errorf("Expected O to lose on time while accepting, got %v", err)
+	}
+}
+
+func TestParseTimeControl(t *testing.T) {
+	tests := []struct {
+		input string
+		want  TimeControl
+	}{
+		{"300+2", TimeControl{Base: 300 * time.Second, Increment: 2 * time.Second}},
+		{"300 2", TimeControl{Base: 300 * time.Second, Increment: 2 * time.Second}},
+		{"180d3", TimeControl{Base: 180 * time.Second, Increment: 3 * time.Second, Delay: true}},
+		{"60", TimeControl{Base: 60 * time.Second}},
+		{"-", TimeControl{}},
+		{"", TimeControl{}},
+	}
+	for _, test := range tests {
+		tc, err := ParseTimeControl(test.input)
+		if err != nil || tc != test.want {
+			t.Errorf("ParseTimeControl(%q) = %+v, %v; want %+v", test.input, tc, err, test.want)
+		}
+	}
+	if s := (TimeControl{Base: 60 * time.Second}).String(); s != "60+0" {
+		t.Errorf("Expected 60+0, got %q", s)
+	}
+	for _, input := range []string{"0+2", "abc", "300+x", "-5"} {
+		if _, err := ParseTimeControl(input); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+}
+
+func TestClock(t *testing.T) {
+	session := NewGameSessionWithPlayers("g1", nil, "alice", nil, "bob")
+	x, o := session.Players[0], session.Players[1]
+	if x.Symbol != X {
+		x, o = o, x
+	}
+	session.SetTimeControl(TimeControl{Base: time.Minute, Increment: 2 * time.Second})
+	if tc := session.GetTimeControl(); tc.String() != "60+2" {
+		t.Errorf("Unexpected time control %s", tc)
+	}
+
+	if err := session.MakeMove(x, &Move{BoardIndex: 4, Position: 4}); err != nil {
+		t.Fatalf("MakeMove failed: %v", err)
+	}
+	if x.Remaining <= time.Minute || x.Remaining > time.Minute+2*time.Second {
+		t.Errorf("Expected the increment to be added, got %s", x.Remaining)
+	}
+	if clock := session.GetGameStateForPlayer(x).Clock; clock == nil || clock.TimeControl != "60+2" || clock.O > 60000 {
+		t.Errorf("Unexpected clock: %+v", clock)
+	}
+
+	o.Remaining = time.Millisecond
+	time.Sleep(5 * time.Millisecond)
+	if loser := session.CheckFlag(); loser != o || session.Winner != X {
+		t.Fatalf("Expected O to lose on time, got %v (winner %s)", loser, session.Winner)
+	}
+	if err := session.MakeMove(o, &Move{BoardIndex: 4, Position: 0}); err == nil {
+		t.Error("Expected an error moving after the flag fell")
+	}
+
+	session = NewGameSessionWithPlayers("g2", nil, "alice", nil, "bob")
+	x = session.Players[0]
+	if x.Symbol != X {
+		x = session.Players[1]
+	}
+	session.SetTimeControl(TimeControl{Base: time.Millisecond})
+	time.Sleep(5 * time.Millisecond)
+	if err := session.MakeMove(x, &Move{BoardIndex: 4, Position: 4}); err != ErrTimeUp || session.Winner != O {
+		t.Errorf("Expected ErrTimeUp and O to win, got %v (winner %s)", err, session.Winner)
+	}
+}
+
+func TestParseClientMessage(t *testing.T) {
+	msg, err := ParseClientMessage([]byte(`{"type":"move","request_id":"7","payload":{"move":"e5"}}`))
+	if err != nil {
+		t.Fatalf("ParseClientMessage failed: %v", err)
+	}
+	move, err := msg.Move()
+	if err != nil || msg.RequestID != "7" || *move != (Move{BoardIndex: 4, Position: 4}) {
+		t.Errorf("Unexpected move message: %+v %v (%v)", msg, move, err)
+	}
+
+	tests := map[string]ClientMessageType{
+		"E5":              ClientMove,
+		"resign":          ClientResign,
+		"r":               ClientResign,
+		"DRAW":            ClientOfferDraw,
+		"accept_draw":     ClientAcceptDraw,
+		"Decline_Draw":    ClientDeclineDraw,
+		"show":            ClientBoard,
+		"EXIT":            ClientQuit,
+		"next":            ClientNextPuzzle,
+		"mute":            ClientMute,
+		"UNMUTE":          ClientUnmute,
+		"undo":            ClientTakeback,
+		"ACCEPT_TAKEBACK": ClientAcceptTakeback,
+	}
+	for text, expected := range tests {
+		msg, err := ParseClientMessage([]byte(text))
+		if err != nil || msg.Type != expected || msg.RequestID != "" {
+			t.Errorf("ParseClientMessage(%q) = %+v (%v), want %s", text, msg, err, expected)
+		}
+	}
+
+	msg, err = ParseClientMessage([]byte("Shout good game, both"))
+	if err != nil || msg.Type != ClientChat {
+		t.Fatalf("Expected a chat message, got %+v (%v)", msg, err)
+	}
+	if chat, err := msg.Chat(); err != nil || chat.Text != "good game, both" || !chat.Spectators {
+		t.Errorf("Unexpected chat payload %+v (%v)", chat, err)
+	}
+
+	for _, bad := range []string{"", `{"payload":{}}`, `{"type":`, `{"type":"teleport"}`} {
+		if _, err := ParseClientMessage([]byte(bad)); err == nil {
+			t.Errorf("ParseClientMessage(%q) succeeded, want error", bad)
+		}
+	}
+	if _, err := (&ClientMessage{Type: ClientMove}).Move(); err == nil {
+		t.Error("Expected an error for a move without payload")
+	}
+}
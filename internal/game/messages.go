@@ -10,6 +10,16 @@ const (
 	MessageTypeGameOver  MessageType = "game_over"
 	MessageTypeDrawOffer MessageType = "draw_offer"
 	MessageTypeWelcome   MessageType = "welcome"
+	MessageTypeAck       MessageType = "ack"
+
+	MessageTypePuzzle       MessageType = "puzzle"
+	MessageTypePuzzleResult MessageType = "puzzle_result"
+
+	MessageTypeDisconnectCountdown MessageType = "disconnect_countdown"
+	MessageTypeRoom                MessageType = "room"
+	MessageTypeRematchOffer        MessageType = "rematch_offer"
+	MessageTypeChat                MessageType = "chat"
+	MessageTypeTakebackOffer       MessageType = "takeback_offer"
 )
 
 type WebSocketMessage struct {
@@ -18,23 +28,44 @@ type WebSocketMessage struct {
 }
 
 type WelcomePayload struct {
-	PlayerID   string `json:"player_id"`
-	PlayerName string `json:"player_name"`
-	Message    string `json:"message"`
+	PlayerID    string `json:"player_id"`
+	PlayerName  string `json:"player_name"`
+	Message     string `json:"message"`
+	ResumeToken string `json:"resume_token,omitempty"` // reconnect with /ws?resume=<token>
 }
 
 type GameStatePayload struct {
 	GameID      string         `json:"game_id"`
 	Board       BoardStateData `json:"board"`
-	CurrentTurn string         `json:"current_turn"` // "X" or "O"
-	YourSymbol  string         `json:"your_symbol"`  // "X" or "O"
-	ActiveBoard int            `json:"active_board"` // -1 for any, 0-8 for specific
-	GameStatus  string         `json:"game_status"`  // "in_progress", "finished"
-	Winner      string         `json:"winner"`       // "X", "O", "Draw", or ""
+	CurrentTurn string         `json:"current_turn"`          // "X" or "O"
+	YourSymbol  string         `json:"your_symbol,omitempty"` // "X" or "O", left out for spectators
+	ActiveBoard int            `json:"active_board"`          // -1 for any, 0-8 for specific
+	GameStatus  string         `json:"game_status"`           // "in_progress", "finished"
+	Winner      string         `json:"winner"`                // "X", "O", "Draw", or ""
 	PlayerXName string         `json:"player_x_name"`
 	PlayerOName string         `json:"player_o_name"`
 	UGNMoves    []string       `json:"ugn_moves"` // Array of UGN notation moves
 	IsYourTurn  bool           `json:"is_your_turn"`
+	Clock       *ClockPayload  `json:"clock,omitempty"` // timed games only
+	Spectators  int            `json:"spectators"`
+	Match       *MatchPayload  `json:"match,omitempty"` // set in rematches and finished games
+}
+
+// MatchPayload is the running score of a series of rematches, from the
+// point of view of the players' current symbols.
+type MatchPayload struct {
+	Round        int     `json:"round"`
+	X            float64 `json:"x"`
+	O            float64 `json:"o"`
+	PreviousGame string  `json:"previous_game,omitempty"`
+}
+
+// ClockPayload holds both players' remaining time in milliseconds. The
+// clock of the player to move is running.
+type ClockPayload struct {
+	TimeControl string `json:"time_control"`
+	X           int64  `json:"x"`
+	O           int64  `json:"o"`
 }
 
 type BoardStateData struct {
@@ -70,11 +101,69 @@ type GameOverPayload struct {
 	Comment    string `json:"comment"` // e.g., "X wins by resignation"
 }
 
+// RoomPayload describes a private room to the player who created it.
+type RoomPayload struct {
+	Code        string `json:"code"`
+	TimeControl string `json:"time_control"` // "-" for untimed
+	Color       string `json:"color"`        // the creator's colour: "X", "O" or "random"
+	Variant     string `json:"variant"`
+	Rated       bool   `json:"rated"`
+	Message     string `json:"message"`
+}
+
+// DisconnectCountdownPayload tells a player how long their disconnected
+// opponent has left to come back before forfeiting.
+type DisconnectCountdownPayload struct {
+	PlayerName  string `json:"player_name"`
+	SecondsLeft int    `json:"seconds_left"`
+	Message     string `json:"message"`
+}
+
+type RematchOfferPayload struct {
+	OfferedBy string `json:"offered_by"`
+	Message   string `json:"message"`
+}
+
+// ChatPayload is a chat message from a player. Symbol is empty for
+// messages from the server.
+type ChatPayload struct {
+	From       string `json:"from"`
+	Symbol     string `json:"symbol"`
+	Text       string `json:"text"`
+	Spectators bool   `json:"spectators"` // also sent to spectators
+	Time       int64  `json:"time"`       // Unix milliseconds
+}
+
+// TakebackOfferPayload asks a player to let their opponent take back Move.
+type TakebackOfferPayload struct {
+	OfferedBy string `json:"offered_by"`
+	Move      string `json:"move"`
+	Message   string `json:"message"`
+}
+
 type DrawOfferPayload struct {
 	OfferedBy string `json:"offered_by"`
 	Message   string `json:"message"`
 }
 
+type PuzzlePayload struct {
+	PuzzleID    string `json:"puzzle_id"`
+	Difficulty  int    `json:"difficulty"`
+	YourSymbol  string `json:"your_symbol"`
+	MovesToFind int    `json:"moves_to_find"`
+	Rating      int    `json:"rating"` // your puzzle rating
+	Message     string `json:"message"`
+}
+
+type PuzzleResultPayload struct {
+	PuzzleID     string   `json:"puzzle_id"`
+	Solved       bool     `json:"solved"`
+	Solution     []string `json:"solution"`
+	Rating       int      `json:"rating"`
+	RatingChange int      `json:"rating_change"`
+	Message      string   `json:"message"`
+}
+
 func (ub *UltimateBoard) GetBoardStateData() BoardStateData {
 	data := BoardStateData{}
 
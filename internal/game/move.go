@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Move struct {
@@ -12,6 +13,13 @@ type Move struct {
 	Position   int // 0-8 corresponding to positions 1-9
 }
 
+// MoveTiming records how long a move took and the mover's clock afterwards.
+// Remaining is zero for untimed games.
+type MoveTiming struct {
+	Elapsed   time.Duration
+	Remaining time.Duration
+}
+
 func ParseMove(moveStr string) (*Move, error) {
 	moveStr = strings.TrimSpace(strings.ToUpper(moveStr))
 
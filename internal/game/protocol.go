@@ -0,0 +1,177 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ClientMessageType names a client-to-server request.
+type ClientMessageType string
+
+const (
+	ClientMove        ClientMessageType = "move"
+	ClientResign      ClientMessageType = "resign"
+	ClientOfferDraw   ClientMessageType = "offer_draw"
+	ClientAcceptDraw  ClientMessageType = "accept_draw"
+	ClientDeclineDraw ClientMessageType = "decline_draw"
+	ClientBoard       ClientMessageType = "board"
+	ClientStatus      ClientMessageType = "status"
+	ClientHelp        ClientMessageType = "help"
+	ClientQuit        ClientMessageType = "quit"
+	ClientNextPuzzle  ClientMessageType = "next_puzzle"
+
+	ClientRematch        ClientMessageType = "rematch"
+	ClientAcceptRematch  ClientMessageType = "accept_rematch"
+	ClientDeclineRematch ClientMessageType = "decline_rematch"
+
+	ClientTakeback        ClientMessageType = "takeback"
+	ClientAcceptTakeback  ClientMessageType = "accept_takeback"
+	ClientDeclineTakeback ClientMessageType = "decline_takeback"
+
+	ClientChat   ClientMessageType = "chat"
+	ClientMute   ClientMessageType = "mute"
+	ClientUnmute ClientMessageType = "unmute"
+)
+
+// ClientMessage is a request such as
+//
+//	{"type":"move","request_id":"7","payload":{"move":"E5"}}
+//
+// Requests carrying a request_id are answered with an ack.
+type ClientMessage struct {
+	Type      ClientMessageType `json:"type"`
+	RequestID string            `json:"request_id,omitempty"`
+	Payload   json.RawMessage   `json:"payload,omitempty"`
+}
+
+type MoveRequestPayload struct {
+	Move string `json:"move"`
+}
+
+// ChatRequestPayload is a chat message to the opponent. With Spectators set
+// it is also sent to everyone watching the game.
+type ChatRequestPayload struct {
+	Text       string `json:"text"`
+	Spectators bool   `json:"spectators,omitempty"`
+}
+
+// AckPayload answers a request that carried a request_id. Error is set when
+// OK is false.
+type AckPayload struct {
+	RequestID string            `json:"request_id"`
+	Type      ClientMessageType `json:"type"`
+	OK        bool              `json:"ok"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// ParseClientMessage decodes a JSON request, or translates one of the plain
+// text commands older clients send ("E5", "resign", "DRAW", ...) into the
+// equivalent request. Text commands are matched case-insensitively. A JSON
+// request with a missing or unknown type is returned along with the error so
+// that it can still be acknowledged.
+func ParseClientMessage(data []byte) (*ClientMessage, error) {
+	text := strings.TrimSpace(string(data))
+	if strings.HasPrefix(text, "{") {
+		var msg ClientMessage
+		if err := json.Unmarshal([]byte(text), &msg); err != nil {
+			return nil, fmt.Errorf("invalid JSON message: %v", err)
+		}
+		switch msg.Type {
+		case ClientMove, ClientResign, ClientOfferDraw, ClientAcceptDraw, ClientDeclineDraw,
+			ClientBoard, ClientStatus, ClientHelp, ClientQuit, ClientNextPuzzle,
+			ClientRematch, ClientAcceptRematch, ClientDeclineRematch,
+			ClientTakeback, ClientAcceptTakeback, ClientDeclineTakeback,
+			ClientChat, ClientMute, ClientUnmute:
+			return &msg, nil
+		case "":
+			return &msg, fmt.Errorf("message has no type")
+		}
+		return &msg, fmt.Errorf("unknown message type %q", msg.Type)
+	}
+	return parseTextCommand(text)
+}
+
+func parseTextCommand(text string) (*ClientMessage, error) {
+	if text == "" {
+		return nil, fmt.Errorf("empty message")
+	}
+	if IsResignation(text) {
+		return &ClientMessage{Type: ClientResign}, nil
+	}
+	if command, rest, found := strings.Cut(text, " "); found && (strings.EqualFold(command, "say") || strings.EqualFold(command, "shout")) {
+		payload, err := json.Marshal(ChatRequestPayload{Text: rest, Spectators: strings.EqualFold(command, "shout")})
+		if err != nil {
+			return nil, err
+		}
+		return &ClientMessage{Type: ClientChat, Payload: payload}, nil
+	}
+	switch strings.ToLower(text) {
+	case "quit", "exit":
+		return &ClientMessage{Type: ClientQuit}, nil
+	case "status":
+		return &ClientMessage{Type: ClientStatus}, nil
+	case "board", "show":
+		return &ClientMessage{Type: ClientBoard}, nil
+	case "help", "?":
+		return &ClientMessage{Type: ClientHelp}, nil
+	case "draw", "offer_draw":
+		return &ClientMessage{Type: ClientOfferDraw}, nil
+	case "accept_draw":
+		return &ClientMessage{Type: ClientAcceptDraw}, nil
+	case "decline_draw":
+		return &ClientMessage{Type: ClientDeclineDraw}, nil
+	case "next", "skip":
+		return &ClientMessage{Type: ClientNextPuzzle}, nil
+	case "rematch":
+		return &ClientMessage{Type: ClientRematch}, nil
+	case "accept_rematch":
+		return &ClientMessage{Type: ClientAcceptRematch}, nil
+	case "decline_rematch":
+		return &ClientMessage{Type: ClientDeclineRematch}, nil
+	case "takeback", "undo":
+		return &ClientMessage{Type: ClientTakeback}, nil
+	case "accept_takeback":
+		return &ClientMessage{Type: ClientAcceptTakeback}, nil
+	case "decline_takeback":
+		return &ClientMessage{Type: ClientDeclineTakeback}, nil
+	case "mute":
+		return &ClientMessage{Type: ClientMute}, nil
+	case "unmute":
+		return &ClientMessage{Type: ClientUnmute}, nil
+	}
+	payload, err := json.Marshal(MoveRequestPayload{Move: text})
+	if err != nil {
+		return nil, err
+	}
+	return &ClientMessage{Type: ClientMove, Payload: payload}, nil
+}
+
+// DecodePayload unmarshals the request's payload into v.
+func (m *ClientMessage) DecodePayload(v interface{}) error {
+	if len(m.Payload) == 0 {
+		return fmt.Errorf("%s message has no payload", m.Type)
+	}
+	if err := json.Unmarshal(m.Payload, v); err != nil {
+		return fmt.Errorf("invalid %s payload: %v", m.Type, err)
+	}
+	return nil
+}
+
+// Chat parses the payload of a chat request.
+func (m *ClientMessage) Chat() (*ChatRequestPayload, error) {
+	var payload ChatRequestPayload
+	if err := m.DecodePayload(&payload); err != nil {
+		return nil, err
+	}
+	return &payload, nil
+}
+
+// Move parses the payload of a move request.
+func (m *ClientMessage) Move() (*Move, error) {
+	var payload MoveRequestPayload
+	if err := m.DecodePayload(&payload); err != nil {
+		return nil, err
+	}
+	return ParseMove(payload.Move)
+}
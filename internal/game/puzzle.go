@@ -0,0 +1,151 @@
+package game
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// PuzzleSession is a single-player session: the player has to find the
+// solver's moves of a solution line and the server plays the replies.
+type PuzzleSession struct {
+	ID         string
+	PuzzleID   string
+	Difficulty int
+	Board      *UltimateBoard
+	Player     *Player
+	Setup      []Move
+	Solution   []Move
+	Finished   bool
+	Solved     bool
+	played     int // solution moves played so far
+	mutex      sync.RWMutex
+}
+
+// NewPuzzleSession sets up the puzzle's start position. The player takes the
+// side to move there.
+func NewPuzzleSession(id, puzzleID string, difficulty int, setup, solution []Move, conn *websocket.Conn, name string) (*PuzzleSession, error) {
+	board := NewUltimateBoard()
+	for i, m := range setup {
+		if err := board.MakeMove(m.BoardIndex, m.Position); err != nil {
+			return nil, fmt.Errorf("setup move %d (%s): %v", i+1, m.ToString(), err)
+		}
+	}
+	if board.State != Undecided || len(solution) == 0 {
+		return nil, fmt.Errorf("puzzle %s has nothing to solve", puzzleID)
+	}
+	return &PuzzleSession{
+		ID:         id,
+		PuzzleID:   puzzleID,
+		Difficulty: difficulty,
+		Board:      board,
+		Player:     &Player{Conn: conn, Symbol: board.CurrentTurn, Name: name},
+		Setup:      setup,
+		Solution:   solution,
+	}, nil
+}
+
+// MakeMove plays the player's move. A legal move that is not the solution
+// fails the puzzle. Otherwise the forced reply, if any, is played and
+// returned.
+func (ps *PuzzleSession) MakeMove(move *Move) (*Move, error) {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	if ps.Finished {
+		return nil, fmt.Errorf("puzzle is already finished")
+	}
+	if !ps.Board.IsValidMove(move.BoardIndex, move.Position) {
+		return nil, fmt.Errorf("invalid move: %s", move.ToString())
+	}
+	if *move != ps.Solution[ps.played] {
+		ps.Finished = true
+		return nil, nil
+	}
+
+	ps.Board.MakeMove(move.BoardIndex, move.Position)
+	ps.played++
+	if ps.played == len(ps.Solution) {
+		ps.Finished = true
+		ps.Solved = true
+		return nil, nil
+	}
+
+	reply := ps.Solution[ps.played]
+	if err := ps.Board.MakeMove(reply.BoardIndex, reply.Position); err != nil {
+		return nil, fmt.Errorf("puzzle %s has an illegal solution: %v", ps.PuzzleID, err)
+	}
+	ps.played++
+	return &reply, nil
+}
+
+// GiveUp ends the puzzle unsolved.
+func (ps *PuzzleSession) GiveUp() error {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	if ps.Finished {
+		return fmt.Errorf("puzzle is already finished")
+	}
+	ps.Finished = true
+	return nil
+}
+
+// MovesToFind is the number of the player's moves still missing.
+func (ps *PuzzleSession) MovesToFind() int {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return (len(ps.Solution) - ps.played + 1) / 2
+}
+
+func (ps *PuzzleSession) SolutionStrings() []string {
+	moves := make([]string, len(ps.Solution))
+	for i := range ps.Solution {
+		moves[i] = ps.Solution[i].ToString()
+	}
+	return moves
+}
+
+// GetGameState describes the puzzle board in the same form as a game, so
+// clients can draw it unchanged.
+func (ps *PuzzleSession) GetGameState() *GameStatePayload {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	playerXName, playerOName := ps.Player.Name, "Puzzle"
+	if ps.Player.Symbol == O {
+		playerXName, playerOName = playerOName, playerXName
+	}
+
+	moves := make([]string, 0, len(ps.Setup)+ps.played)
+	for _, m := range ps.Setup {
+		moves = append(moves, m.ToString())
+	}
+	for _, m := range ps.Solution[:ps.played] {
+		moves = append(moves, m.ToString())
+	}
+
+	gameStatus := "in_progress"
+	winner := ""
+	if ps.Finished {
+		gameStatus = "finished"
+		if ps.Solved {
+			winner = ps.Player.Symbol.String()
+		}
+	}
+
+	return &GameStatePayload{
+		GameID:      ps.ID,
+		Board:       ps.Board.GetBoardStateData(),
+		CurrentTurn: ps.Board.CurrentTurn.String(),
+		YourSymbol:  ps.Player.Symbol.String(),
+		ActiveBoard: ps.Board.ActiveBoard,
+		GameStatus:  gameStatus,
+		Winner:      winner,
+		PlayerXName: playerXName,
+		PlayerOName: playerOName,
+		UGNMoves:    moves,
+		IsYourTurn:  !ps.Finished && ps.Board.CurrentTurn == ps.Player.Symbol,
+	}
+}
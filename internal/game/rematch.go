@@ -0,0 +1,151 @@
+package game
+
+import "fmt"
+
+// MatchScore is a running score over a series of rematches, counted per
+// player slot since the players swap symbols every game. A draw is worth
+// half a point.
+type MatchScore [2]float64
+
+func (gs *GameSession) slot(player *Player) int {
+	for i, p := range gs.Players {
+		if p == player {
+			return i
+		}
+	}
+	return -1
+}
+
+// Score returns the match score including this game's result once it is
+// finished.
+func (gs *GameSession) Score() MatchScore {
+	gs.mutex.RLock()
+	defer gs.mutex.RUnlock()
+	return gs.score()
+}
+
+func (gs *GameSession) score() MatchScore {
+	score := gs.MatchScore
+	if !gs.Finished || gs.Aborted {
+		return score
+	}
+	for i, p := range gs.Players {
+		if p == nil {
+			continue
+		}
+		switch gs.Winner {
+		case p.Symbol:
+			score[i]++
+		case Empty:
+			score[i] += 0.5
+		}
+	}
+	return score
+}
+
+func (gs *GameSession) OfferRematch(player *Player) error {
+	gs.mutex.Lock()
+	defer gs.mutex.Unlock()
+
+	if !gs.Finished {
+		return fmt.Errorf("game is not finished yet")
+	}
+	if gs.Players[0] == nil || gs.Players[1] == nil {
+		return fmt.Errorf("your opponent has left")
+	}
+	if gs.RematchOfferedBy != nil {
+		return fmt.Errorf("rematch offer already pending")
+	}
+	gs.RematchOfferedBy = player
+	return nil
+}
+
+func (gs *GameSession) DeclineRematch(player *Player) error {
+	gs.mutex.Lock()
+	defer gs.mutex.Unlock()
+
+	if gs.RematchOfferedBy == nil {
+		return fmt.Errorf("no rematch offer pending")
+	}
+	if gs.RematchOfferedBy == player {
+		return fmt.Errorf("cannot decline your own rematch offer")
+	}
+	gs.RematchOfferedBy = nil
+	return nil
+}
+
+// acceptRematch starts the next game of the match: the same players in the
+// same slots with their symbols swapped, and the same time control. Mutes
+// carry over and the spectators move to the new game.
+func (gs *GameSession) acceptRematch(player *Player, id string) (*GameSession, error) {
+	gs.mutex.Lock()
+	defer gs.mutex.Unlock()
+
+	if gs.RematchOfferedBy == nil {
+		return nil, fmt.Errorf("no rematch offer pending")
+	}
+	if gs.RematchOfferedBy == player {
+		return nil, fmt.Errorf("cannot accept your own rematch offer")
+	}
+	if gs.Players[0] == nil || gs.Players[1] == nil {
+		return nil, fmt.Errorf("your opponent has left")
+	}
+	if gs.Rematch != nil {
+		return nil, fmt.Errorf("rematch already started")
+	}
+
+	var players [2]*Player
+	for i, p := range gs.Players {
+		symbol := X
+		if p.Symbol == X {
+			symbol = O
+		}
+		players[i] = &Player{Conn: p.Conn, Symbol: symbol, Name: p.Name, Muted: p.Muted}
+	}
+	next := newStartedSession(id, players[0], players[1])
+	next.Round = gs.round() + 1
+	next.PreviousGame = gs.ID
+	next.MatchScore = gs.score()
+	next.Rated = gs.Rated
+
+	next.spectators = gs.spectators
+	gs.spectators = nil
+
+	gs.RematchOfferedBy = nil
+	gs.Rematch = next
+	return next, nil
+}
+
+// round is the game's number in its match, counting from 1.
+func (gs *GameSession) round() int {
+	if gs.Round == 0 {
+		return 1
+	}
+	return gs.Round
+}
+
+// Counterpart returns the player in the same slot of another game of the
+// match.
+func (gs *GameSession) Counterpart(player *Player, other *GameSession) *Player {
+	gs.mutex.RLock()
+	slot := gs.slot(player)
+	gs.mutex.RUnlock()
+	if slot < 0 {
+		return nil
+	}
+	return other.Players[slot]
+}
+
+// StartRematch accepts the opponent's rematch offer on session and adds the
+// new game to the manager.
+func (gm *GameManager) StartRematch(session *GameSession, player *Player) (*GameSession, error) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	next, err := session.acceptRematch(player, gm.newSessionID())
+	if err != nil {
+		return nil, err
+	}
+	gm.sessions[next.ID] = next
+	return next, nil
+}
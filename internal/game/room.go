@@ -0,0 +1,154 @@
+package game
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// VariantStandard is the only variant the server plays so far.
+const VariantStandard = "standard"
+
+// RoomSettings are chosen by the player who creates a private room.
+type RoomSettings struct {
+	TimeControl TimeControl
+	HostColor   CellState // X or O, or Empty for a random pick
+	Variant     string
+	Rated       bool // the game and its rematches allow no takebacks
+}
+
+// ParseRoomSettings reads the settings a room is created with; empty
+// values mean untimed, random colours, the standard variant and a casual
+// game.
+func ParseRoomSettings(timeControl, color, variant, rated string) (RoomSettings, error) {
+	var settings RoomSettings
+	var err error
+	if settings.TimeControl, err = ParseTimeControl(timeControl); err != nil {
+		return settings, err
+	}
+	switch strings.ToLower(color) {
+	case "", "random":
+		settings.HostColor = Empty
+	case "x":
+		settings.HostColor = X
+	case "o":
+		settings.HostColor = O
+	default:
+		return settings, fmt.Errorf("invalid colour %q: use x, o or random", color)
+	}
+	switch strings.ToLower(variant) {
+	case "", VariantStandard:
+		settings.Variant = VariantStandard
+	default:
+		return settings, fmt.Errorf("unsupported variant %q (only %s is available)", variant, VariantStandard)
+	}
+	if rated != "" {
+		if settings.Rated, err = strconv.ParseBool(rated); err != nil {
+			return settings, fmt.Errorf("invalid rated setting %q: use 1 or 0", rated)
+		}
+	}
+	return settings, nil
+}
+
+func (s RoomSettings) ColorString() string {
+	if s.HostColor == Empty {
+		return "random"
+	}
+	return s.HostColor.String()
+}
+
+// Room is a private game waiting for the host's friend to join by code.
+type Room struct {
+	Code      string
+	Settings  RoomSettings
+	HostConn  *websocket.Conn
+	HostName  string
+	CreatedAt time.Time
+}
+
+// roomCodeCharset leaves out characters that are easy to misread.
+const roomCodeCharset = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+func randomString(charset string, length int) string {
+	result := make([]byte, length)
+	for i := range result {
+		num, _ := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+		result[i] = charset[num.Int64()]
+	}
+	return string(result)
+}
+
+// newSessionID returns an unused game ID. The caller holds the lock.
+func (gm *GameManager) newSessionID() string {
+	const charset = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	id := randomString(charset, 8)
+	for gm.sessions[id] != nil {
+		id = randomString(charset, 8)
+	}
+	return id
+}
+
+func (gm *GameManager) CreateRoom(conn *websocket.Conn, name string, settings RoomSettings) *Room {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	code := randomString(roomCodeCharset, 6)
+	for gm.rooms[code] != nil {
+		code = randomString(roomCodeCharset, 6)
+	}
+	room := &Room{
+		Code:      code,
+		Settings:  settings,
+		HostConn:  conn,
+		HostName:  name,
+		CreatedAt: time.Now(),
+	}
+	gm.rooms[code] = room
+	return room
+}
+
+func (gm *GameManager) GetRoom(code string) *Room {
+	gm.mutex.RLock()
+	defer gm.mutex.RUnlock()
+	return gm.rooms[strings.ToUpper(code)]
+}
+
+func (gm *GameManager) CloseRoom(code string) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+	delete(gm.rooms, code)
+}
+
+// JoinRoom seats the second player in the room and starts its game. The
+// room is closed and the new session added to the manager.
+func (gm *GameManager) JoinRoom(code string, conn *websocket.Conn, name string) (*GameSession, *Room, error) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	room := gm.rooms[strings.ToUpper(code)]
+	if room == nil {
+		return nil, nil, fmt.Errorf("no room with code %s", strings.ToUpper(code))
+	}
+	delete(gm.rooms, room.Code)
+
+	hostSymbol, guestSymbol := room.Settings.HostColor, room.Settings.HostColor
+	if hostSymbol == Empty {
+		hostSymbol, guestSymbol = randomPlayerAssignment()
+	} else if hostSymbol == X {
+		guestSymbol = O
+	} else {
+		guestSymbol = X
+	}
+
+	session := newStartedSession(gm.newSessionID(),
+		&Player{Conn: room.HostConn, Symbol: hostSymbol, Name: room.HostName},
+		&Player{Conn: conn, Symbol: guestSymbol, Name: name})
+	session.Rated = room.Settings.Rated
+	gm.sessions[session.ID] = session
+	return session, room, nil
+}
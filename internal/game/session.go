@@ -2,6 +2,7 @@ package game
 
 import (
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"math/big"
 	"sync"
@@ -11,30 +12,53 @@ import (
 )
 
 type Player struct {
-	Conn     *websocket.Conn
-	Symbol   CellState
-	Name     string
-	LastSeen time.Time
+	Conn      *websocket.Conn
+	Symbol    CellState
+	Name      string
+	LastSeen  time.Time
+	Remaining time.Duration // clock at the end of the player's last move
+	Muted     bool          // the player has muted their opponent's chat
+}
+
+// Spectator watches a game without playing in it.
+type Spectator struct {
+	Conn *websocket.Conn
+	Name string
 }
 
 type GameSession struct {
-	ID               string
-	Board            *UltimateBoard
-	Players          [2]*Player
-	Started          bool
-	Finished         bool
-	Winner           CellState
-	Logger           GameLogger
-	DrawOfferPending bool
-	DrawOfferedBy    *Player
-	mutex            sync.RWMutex
+	ID                string
+	Board             *UltimateBoard
+	Players           [2]*Player
+	Started           bool
+	Finished          bool
+	Winner            CellState
+	Aborted           bool // ended without a result, see AbandonGame
+	Logger            GameLogger
+	TimeControl       TimeControl
+	DrawOfferPending  bool
+	DrawOfferedBy     *Player
+	RematchOfferedBy  *Player
+	TakebackOfferedBy *Player
+	Rated             bool         // rated games allow no takebacks
+	Rematch           *GameSession // the next game of the match, once accepted
+	Round             int          // game number in a rematch series; 0 for a single game
+	PreviousGame      string
+	MatchScore        MatchScore // score before this game
+	moves             []Move
+	takebacks         int // moves taken back this game, by either player
+	spectators        []*Spectator
+	lastMoveAt        time.Time
+	mutex             sync.RWMutex
 }
 
 type GameLogger interface {
 	StartGame(gameID, playerX, playerO string) error
-	LogMove(move *Move, board *UltimateBoard, beforeGameState BoardState, beforeSmallState BoardState) error
+	LogMove(move *Move, board *UltimateBoard, beforeGameState BoardState, beforeSmallState BoardState, timing MoveTiming) error
 	EndGame(result string) error
 	EndGameWithComment(result, comment string) error
+	SetTag(name, value string) error
+	UndoMove() error
 	IsGameStarted() bool
 	GetUGNMovesString() string
 }
@@ -52,26 +76,23 @@ func NewGameSession(id string) *GameSession {
 }
 
 func NewGameSessionWithPlayers(id string, player1Conn *websocket.Conn, player1Name string, player2Conn *websocket.Conn, player2Name string) *GameSession {
-	session := NewGameSession(id)
-
 	// Randomly assign X and O
 	player1Symbol, player2Symbol := randomPlayerAssignment()
 
-	session.Players[0] = &Player{
-		Conn:     player1Conn,
-		Symbol:   player1Symbol,
-		Name:     player1Name,
-		LastSeen: time.Now(),
-	}
+	return newStartedSession(id,
+		&Player{Conn: player1Conn, Symbol: player1Symbol, Name: player1Name},
+		&Player{Conn: player2Conn, Symbol: player2Symbol, Name: player2Name})
+}
 
-	session.Players[1] = &Player{
-		Conn:     player2Conn,
-		Symbol:   player2Symbol,
-		Name:     player2Name,
-		LastSeen: time.Now(),
-	}
+func newStartedSession(id string, player1, player2 *Player) *GameSession {
+	session := NewGameSession(id)
+	player1.LastSeen = time.Now()
+	player2.LastSeen = time.Now()
+	session.Players[0] = player1
+	session.Players[1] = player2
 
 	session.Started = true
+	session.lastMoveAt = time.Now()
 	return session
 }
 
@@ -118,6 +139,7 @@ func (gs *GameSession) AddPlayer(conn *websocket.Conn, name string) (*Player, er
 
 	if gs.Players[0] != nil && gs.Players[1] != nil && !gs.Started {
 		gs.Started = true
+		gs.lastMoveAt = time.Now()
 
 		if gs.Logger != nil {
 			playerXName := gs.Players[0].Name
@@ -144,6 +166,155 @@ func (gs *GameSession) RemovePlayer(conn *websocket.Conn) {
 	}
 }
 
+// DisconnectPlayer keeps the player's slot reserved but drops its
+// connection until ReconnectPlayer or ReleasePlayer is called.
+func (gs *GameSession) DisconnectPlayer(player *Player) {
+	gs.mutex.Lock()
+	defer gs.mutex.Unlock()
+
+	player.Conn = nil
+	player.LastSeen = time.Now()
+}
+
+// ReconnectPlayer binds a new connection to a reserved player slot.
+func (gs *GameSession) ReconnectPlayer(player *Player, conn *websocket.Conn) error {
+	gs.mutex.Lock()
+	defer gs.mutex.Unlock()
+
+	for _, p := range gs.Players {
+		if p == player {
+			player.Conn = conn
+			player.LastSeen = time.Now()
+			return nil
+		}
+	}
+	return fmt.Errorf("player is no longer in this game")
+}
+
+// ReleasePlayer frees the player's slot.
+func (gs *GameSession) ReleasePlayer(player *Player) {
+	gs.mutex.Lock()
+	defer gs.mutex.Unlock()
+
+	for i, p := range gs.Players {
+		if p == player {
+			gs.Players[i] = nil
+			break
+		}
+	}
+}
+
+// SetMuted mutes or unmutes the opponent's chat for player.
+func (gs *GameSession) SetMuted(player *Player, muted bool) {
+	gs.mutex.Lock()
+	defer gs.mutex.Unlock()
+	player.Muted = muted
+}
+
+// HasMuted reports whether player has muted their opponent's chat.
+func (gs *GameSession) HasMuted(player *Player) bool {
+	gs.mutex.RLock()
+	defer gs.mutex.RUnlock()
+	return player.Muted
+}
+
+func (gs *GameSession) AddSpectator(conn *websocket.Conn, name string) *Spectator {
+	gs.mutex.Lock()
+	defer gs.mutex.Unlock()
+
+	spectator := &Spectator{Conn: conn, Name: name}
+	gs.spectators = append(gs.spectators, spectator)
+	return spectator
+}
+
+// RemoveSpectator stops spectator watching the game, or the rematch it
+// followed the players to.
+func (gs *GameSession) RemoveSpectator(spectator *Spectator) {
+	gs.mutex.Lock()
+	for i, s := range gs.spectators {
+		if s == spectator {
+			gs.spectators = append(gs.spectators[:i], gs.spectators[i+1:]...)
+			gs.mutex.Unlock()
+			return
+		}
+	}
+	next := gs.Rematch
+	gs.mutex.Unlock()
+
+	if next != nil {
+		next.RemoveSpectator(spectator)
+	}
+}
+
+// Latest returns the game the match has moved on to through rematches, or
+// gs itself.
+func (gs *GameSession) Latest() *GameSession {
+	gs.mutex.RLock()
+	next := gs.Rematch
+	gs.mutex.RUnlock()
+
+	if next == nil {
+		return gs
+	}
+	return next.Latest()
+}
+
+// Spectators returns a snapshot of the game's spectators.
+func (gs *GameSession) Spectators() []*Spectator {
+	gs.mutex.RLock()
+	defer gs.mutex.RUnlock()
+
+	spectators := make([]*Spectator, len(gs.spectators))
+	copy(spectators, gs.spectators)
+	return spectators
+}
+
+// PlayerList returns a copy of each seated player, so their connections
+// can be read without racing a reconnect.
+func (gs *GameSession) PlayerList() []Player {
+	gs.mutex.RLock()
+	defer gs.mutex.RUnlock()
+
+	var players []Player
+	for _, player := range gs.Players {
+		if player != nil {
+			players = append(players, *player)
+		}
+	}
+	return players
+}
+
+// PlayerConn returns the player's current connection, or nil while they are
+// disconnected.
+func (gs *GameSession) PlayerConn(player *Player) *websocket.Conn {
+	gs.mutex.RLock()
+	defer gs.mutex.RUnlock()
+	return player.Conn
+}
+
+// PlayerByConn returns the seated player using conn, if any.
+func (gs *GameSession) PlayerByConn(conn *websocket.Conn) *Player {
+	gs.mutex.RLock()
+	defer gs.mutex.RUnlock()
+
+	for _, player := range gs.Players {
+		if player != nil && player.Conn == conn {
+			return player
+		}
+	}
+	return nil
+}
+
+func (gs *GameSession) SpectatorCount() int {
+	gs.mutex.RLock()
+	defer gs.mutex.RUnlock()
+	return len(gs.spectators)
+}
+
+func (p *Player) IsConnected() bool {
+	return p.Conn != nil
+}
+
 func (gs *GameSession) GetCurrentPlayer() *Player {
 	gs.mutex.RLock()
 	defer gs.mutex.RUnlock()
@@ -174,6 +345,82 @@ func (gs *GameSession) GetOpponent(currentPlayer *Player) *Player {
 	return nil
 }
 
+// ErrTimeUp is returned by MakeMove when the player's flag fell before the
+// move arrived; the game has been lost on time.
+var ErrTimeUp = errors.New("your time has run out")
+
+// SetTimeControl sets both clocks to the control's base time and starts the
+// clock of the player to move. It records the control in the game record.
+func (gs *GameSession) SetTimeControl(tc TimeControl) error {
+	gs.mutex.Lock()
+	defer gs.mutex.Unlock()
+
+	if len(gs.moves) > 0 {
+		return fmt.Errorf("cannot change the time control after the first move")
+	}
+
+	gs.TimeControl = tc
+	for _, player := range gs.Players {
+		if player != nil {
+			player.Remaining = tc.Base
+		}
+	}
+	gs.lastMoveAt = time.Now()
+
+	if gs.Logger != nil && gs.Logger.IsGameStarted() {
+		return gs.Logger.SetTag("TimeControl", tc.String())
+	}
+	return nil
+}
+
+// timeLeft is the player's clock right now, counting the time spent on the
+// current move if it is theirs.
+func (gs *GameSession) timeLeft(player *Player) time.Duration {
+	if gs.Finished || player.Symbol != gs.Board.CurrentTurn {
+		return player.Remaining
+	}
+	left := player.Remaining - gs.TimeControl.charge(time.Since(gs.lastMoveAt))
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+// CheckFlag ends a timed game if the player to move has run out of time,
+// and returns that player.
+func (gs *GameSession) CheckFlag() *Player {
+	gs.mutex.Lock()
+	defer gs.mutex.Unlock()
+
+	if !gs.Started || gs.Finished || !gs.TimeControl.Timed() {
+		return nil
+	}
+	for _, player := range gs.Players {
+		if player != nil && player.Symbol == gs.Board.CurrentTurn && gs.timeLeft(player) <= 0 {
+			gs.flag(player)
+			return player
+		}
+	}
+	return nil
+}
+
+// flag ends the game with player losing on time. The caller holds the lock.
+func (gs *GameSession) flag(player *Player) {
+	player.Remaining = 0
+	gs.Finished = true
+	gs.DrawOfferPending = false
+	gs.DrawOfferedBy = nil
+	if player.Symbol == X {
+		gs.Winner = O
+	} else {
+		gs.Winner = X
+	}
+
+	if gs.Logger != nil && gs.Logger.IsGameStarted() {
+		gs.Logger.EndGameWithComment(gs.Winner.String(), fmt.Sprintf("%s wins on time", gs.Winner))
+	}
+}
+
 func (gs *GameSession) MakeMove(player *Player, move *Move) error {
 	gs.mutex.Lock()
 	defer gs.mutex.Unlock()
@@ -190,6 +437,14 @@ func (gs *GameSession) MakeMove(player *Player, move *Move) error {
 		return fmt.Errorf("it's not your turn")
 	}
 
+	now := time.Now()
+	elapsed := now.Sub(gs.lastMoveAt)
+	used := gs.TimeControl.charge(elapsed)
+	if gs.TimeControl.Timed() && used >= player.Remaining {
+		gs.flag(player)
+		return ErrTimeUp
+	}
+
 	beforeGameState := gs.Board.State
 	beforeSmallState := gs.Board.Boards[move.BoardIndex].State
 
@@ -198,8 +453,21 @@ func (gs *GameSession) MakeMove(player *Player, move *Move) error {
 		return err
 	}
 
+	gs.moves = append(gs.moves, *move)
+	gs.TakebackOfferedBy = nil
+
+	timing := MoveTiming{Elapsed: elapsed}
+	gs.lastMoveAt = now
+	if gs.TimeControl.Timed() {
+		player.Remaining -= used
+		if !gs.TimeControl.Delay {
+			player.Remaining += gs.TimeControl.Increment
+		}
+		timing.Remaining = player.Remaining
+	}
+
 	if gs.Logger != nil && gs.Logger.IsGameStarted() {
-		err := gs.Logger.LogMove(move, gs.Board, beforeGameState, beforeSmallState)
+		err := gs.Logger.LogMove(move, gs.Board, beforeGameState, beforeSmallState, timing)
 		if err != nil {
 		}
 	}
@@ -271,6 +539,52 @@ func (gs *GameSession) ResignGame(player *Player) error {
 	return nil
 }
 
+// MinMovesForForfeit is how many moves a game needs before leaving it loses;
+// a game abandoned earlier is aborted instead.
+const MinMovesForForfeit = 2
+
+// AbandonGame ends the game after player has left it for good. The player
+// forfeits, unless fewer than MinMovesForForfeit moves were played, in which
+// case the game is aborted without a result. It reports whether the game was
+// aborted.
+func (gs *GameSession) AbandonGame(player *Player) (bool, error) {
+	gs.mutex.Lock()
+	defer gs.mutex.Unlock()
+
+	if !gs.Started {
+		return false, fmt.Errorf("game has not started yet")
+	}
+
+	if gs.Finished {
+		return false, fmt.Errorf("game is already finished")
+	}
+
+	gs.Finished = true
+	gs.DrawOfferPending = false
+	gs.DrawOfferedBy = nil
+
+	result, comment := "*", fmt.Sprintf("Game aborted, %s abandoned", player.Symbol)
+	if len(gs.moves) < MinMovesForForfeit {
+		gs.Aborted = true
+	} else {
+		if player.Symbol == X {
+			gs.Winner = O
+		} else {
+			gs.Winner = X
+		}
+		result = gs.Winner.String()
+		comment = fmt.Sprintf("%s wins by abandonment", gs.Winner)
+	}
+
+	if gs.Logger != nil && gs.Logger.IsGameStarted() {
+		if err := gs.Logger.EndGameWithComment(result, comment); err != nil {
+			return gs.Aborted, err
+		}
+	}
+
+	return gs.Aborted, nil
+}
+
 func (gs *GameSession) OfferDraw(player *Player) error {
 	gs.mutex.Lock()
 	defer gs.mutex.Unlock()
@@ -329,6 +643,30 @@ func (gs *GameSession) DeclineDraw() error {
 	return nil
 }
 
+// Snapshot returns a copy of the board and the moves played so far.
+func (gs *GameSession) Snapshot() (*UltimateBoard, []Move) {
+	gs.mutex.RLock()
+	defer gs.mutex.RUnlock()
+
+	moves := make([]Move, len(gs.moves))
+	copy(moves, gs.moves)
+	return gs.Board.Clone(), moves
+}
+
+// GetTimeControl returns the game's time control, which is zero for
+// untimed games.
+func (gs *GameSession) GetTimeControl() TimeControl {
+	gs.mutex.RLock()
+	defer gs.mutex.RUnlock()
+	return gs.TimeControl
+}
+
+func (gs *GameSession) IsFinished() bool {
+	gs.mutex.RLock()
+	defer gs.mutex.RUnlock()
+	return gs.Finished
+}
+
 func (gs *GameSession) GetGameStatus() string {
 	gs.mutex.RLock()
 	defer gs.mutex.RUnlock()
@@ -343,6 +681,10 @@ func (gs *GameSession) GetGameStatus() string {
 		return fmt.Sprintf("Waiting for players (%d/2 connected)", playerCount)
 	}
 
+	if gs.Aborted {
+		return "Game Over - Aborted"
+	}
+
 	if gs.Finished {
 		switch gs.Winner {
 		case X:
@@ -368,7 +710,7 @@ func (gs *GameSession) BroadcastToAll(message string) {
 	defer gs.mutex.RUnlock()
 
 	for _, player := range gs.Players {
-		if player != nil {
+		if player != nil && player.Conn != nil {
 			player.Conn.WriteMessage(websocket.TextMessage, []byte(message))
 		}
 	}
@@ -420,7 +762,9 @@ func (gs *GameSession) GetGameStateForPlayer(player *Player) *GameStatePayload {
 
 	gameStatus := "in_progress"
 	winner := ""
-	if gs.Finished {
+	if gs.Aborted {
+		gameStatus = "aborted"
+	} else if gs.Finished {
 		gameStatus = "finished"
 		switch gs.Winner {
 		case X:
@@ -444,17 +788,59 @@ func (gs *GameSession) GetGameStateForPlayer(player *Player) *GameStatePayload {
 		PlayerOName: playerOName,
 		UGNMoves:    gs.GetUGNMoves(),
 		IsYourTurn:  player != nil && gs.Board.CurrentTurn == player.Symbol && !gs.Finished,
+		Clock:       gs.clockPayload(),
+		Spectators:  len(gs.spectators),
+		Match:       gs.matchPayload(),
+	}
+}
+
+func (gs *GameSession) matchPayload() *MatchPayload {
+	if gs.Round == 0 && !gs.Finished {
+		return nil
+	}
+	match := &MatchPayload{Round: gs.round(), PreviousGame: gs.PreviousGame}
+	score := gs.score()
+	for i, p := range gs.Players {
+		if p == nil {
+			continue
+		}
+		if p.Symbol == X {
+			match.X = score[i]
+		} else {
+			match.O = score[i]
+		}
+	}
+	return match
+}
+
+func (gs *GameSession) clockPayload() *ClockPayload {
+	if !gs.TimeControl.Timed() {
+		return nil
+	}
+	clock := &ClockPayload{TimeControl: gs.TimeControl.String()}
+	for _, p := range gs.Players {
+		if p == nil {
+			continue
+		}
+		if p.Symbol == X {
+			clock.X = gs.timeLeft(p).Milliseconds()
+		} else {
+			clock.O = gs.timeLeft(p).Milliseconds()
+		}
 	}
+	return clock
 }
 
 type GameManager struct {
 	sessions map[string]*GameSession
+	rooms    map[string]*Room
 	mutex    sync.RWMutex
 }
 
 func NewGameManager() *GameManager {
 	return &GameManager{
 		sessions: make(map[string]*GameSession),
+		rooms:    make(map[string]*Room),
 	}
 }
 
@@ -0,0 +1,131 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// MaxTakebacks is how many moves may be taken back in a game, shared
+// between the two players.
+const MaxTakebacks = 2
+
+// RequestTakeback asks the opponent to let player take back their last
+// move, which must be the last move of the game. It returns that move.
+func (gs *GameSession) RequestTakeback(player *Player) (*Move, error) {
+	gs.mutex.Lock()
+	defer gs.mutex.Unlock()
+
+	if !gs.Started {
+		return nil, fmt.Errorf("game has not started yet")
+	}
+	if gs.Finished {
+		return nil, fmt.Errorf("game is already finished")
+	}
+	if gs.Rated {
+		return nil, fmt.Errorf("takebacks are not allowed in rated games")
+	}
+	if gs.TakebackOfferedBy != nil {
+		return nil, fmt.Errorf("takeback request already pending")
+	}
+	if len(gs.moves) == 0 || gs.Board.CurrentTurn == player.Symbol {
+		return nil, fmt.Errorf("you can only take back your own last move")
+	}
+	if gs.slot(player) < 0 {
+		return nil, fmt.Errorf("player is no longer in this game")
+	}
+	if gs.takebacks >= MaxTakebacks {
+		return nil, fmt.Errorf("no takebacks left (at most %d per game)", MaxTakebacks)
+	}
+
+	gs.TakebackOfferedBy = player
+	move := gs.moves[len(gs.moves)-1]
+	return &move, nil
+}
+
+func (gs *GameSession) DeclineTakeback(player *Player) error {
+	gs.mutex.Lock()
+	defer gs.mutex.Unlock()
+
+	if gs.TakebackOfferedBy == nil {
+		return fmt.Errorf("no takeback request pending")
+	}
+	if gs.TakebackOfferedBy == player {
+		return fmt.Errorf("cannot decline your own takeback request")
+	}
+	gs.TakebackOfferedBy = nil
+	return nil
+}
+
+// AcceptTakeback undoes the requester's last move: the board is rebuilt
+// from the remaining moves, the move is dropped from the game record and it
+// is the requester's turn again. The increment they gained for the move is
+// taken off their clock; the time they spent on it is not given back. The
+// accepting player's clock was running until now, so the time they spent
+// deciding is charged to them, and they lose on time if it ran out.
+func (gs *GameSession) AcceptTakeback(player *Player) (*Move, error) {
+	gs.mutex.Lock()
+	defer gs.mutex.Unlock()
+
+	requester := gs.TakebackOfferedBy
+	if requester == nil {
+		return nil, fmt.Errorf("no takeback request pending")
+	}
+	if requester == player {
+		return nil, fmt.Errorf("cannot accept your own takeback request")
+	}
+	if gs.Finished {
+		return nil, fmt.Errorf("game is already finished")
+	}
+	if gs.TimeControl.Timed() {
+		left := gs.timeLeft(player)
+		if left <= 0 {
+			gs.flag(player)
+			return nil, ErrTimeUp
+		}
+		player.Remaining = left
+	}
+
+	last := gs.moves[len(gs.moves)-1]
+	moves := gs.moves[:len(gs.moves)-1]
+	board := NewUltimateBoard()
+	for _, move := range moves {
+		if err := board.MakeMove(move.BoardIndex, move.Position); err != nil {
+			return nil, fmt.Errorf("failed to replay game: %v", err)
+		}
+	}
+
+	gs.Board = board
+	gs.moves = moves
+	gs.TakebackOfferedBy = nil
+	gs.takebacks++
+	gs.lastMoveAt = time.Now()
+	if gs.TimeControl.Timed() && !gs.TimeControl.Delay {
+		requester.Remaining -= gs.TimeControl.Increment
+		if requester.Remaining < 0 {
+			requester.Remaining = 0
+		}
+	}
+
+	if gs.Logger != nil && gs.Logger.IsGameStarted() {
+		if err := gs.Logger.UndoMove(); err != nil {
+			return &last, err
+		}
+		if err := gs.Logger.SetTag("Takebacks", strconv.Itoa(gs.takebacks)); err != nil {
+			return &last, err
+		}
+	}
+	return &last, nil
+}
+
+// TakebacksLeft returns how many more moves may be taken back this game, or
+// 0 in rated games.
+func (gs *GameSession) TakebacksLeft() int {
+	gs.mutex.RLock()
+	defer gs.mutex.RUnlock()
+
+	if gs.Rated {
+		return 0
+	}
+	return MaxTakebacks - gs.takebacks
+}
@@ -48,23 +48,42 @@ func (sm *SimpleMatchmaker) RemovePlayer(playerID string) error {
 	return fmt.Errorf("player %s not found in queue", playerID)
 }
 
+// FindMatch pairs players in arrival order. Each time control has its own
+// queue: only players asking for the same control are matched.
 func (sm *SimpleMatchmaker) FindMatch() []*GameMatch {
 	sm.mutex.Lock()
 	defer sm.mutex.Unlock()
 	var matches []*GameMatch
-	for len(sm.queue) >= sm.maxSize {
-		matchedPlayers := make([]*PlayerRequest, sm.maxSize)
-		copy(matchedPlayers, sm.queue[:sm.maxSize])
-		sm.queue = sm.queue[sm.maxSize:]
-		gameID := generateGameID()
+	waiting := make(map[string][]*PlayerRequest)
+	matched := make(map[*PlayerRequest]bool)
+	for _, player := range sm.queue {
+		group := append(waiting[player.TimeControl], player)
+		if len(group) < sm.maxSize {
+			waiting[player.TimeControl] = group
+			continue
+		}
+		delete(waiting, player.TimeControl)
+		for _, p := range group {
+			matched[p] = true
+		}
 		match := &GameMatch{
-			GameID:    gameID,
-			Players:   matchedPlayers,
-			CreatedAt: time.Now(),
-			Mode:      SimpleMode,
+			GameID:      generateGameID(),
+			Players:     group,
+			CreatedAt:   time.Now(),
+			Mode:        SimpleMode,
+			TimeControl: player.TimeControl,
 		}
 		matches = append(matches, match)
 	}
+	if len(matched) > 0 {
+		remaining := make([]*PlayerRequest, 0, len(sm.queue)-len(matched))
+		for _, player := range sm.queue {
+			if !matched[player] {
+				remaining = append(remaining, player)
+			}
+		}
+		sm.queue = remaining
+	}
 	return matches
 }
 
@@ -17,21 +17,23 @@ const (
 
 // Player looking for a match
 type PlayerRequest struct {
-	ID         string          // Unique player ID
-	Name       string          // Player display name
-	Connection *websocket.Conn // WebSocket connection
-	JoinedAt   time.Time       // When player joined the queue
-	Mode       MatchmakingMode // Matchmaking mode preference
+	ID          string          // Unique player ID
+	Name        string          // Player display name
+	Connection  *websocket.Conn // WebSocket connection
+	JoinedAt    time.Time       // When player joined the queue
+	Mode        MatchmakingMode // Matchmaking mode preference
+	TimeControl string          // Requested time control, e.g. "300+2"; "" for untimed
 	// EloRating  int             // Player's ELO rating
 	// Preferences map[string]interface{} // Preferences (e.g. X or O)
 }
 
 // Match between players
 type GameMatch struct {
-	GameID    string           // Unique game identifier
-	Players   []*PlayerRequest // Matched players
-	CreatedAt time.Time        // When the match was created
-	Mode      MatchmakingMode  // Matchmaking mode used
+	GameID      string           // Unique game identifier
+	Players     []*PlayerRequest // Matched players
+	CreatedAt   time.Time        // When the match was created
+	Mode        MatchmakingMode  // Matchmaking mode used
+	TimeControl string           // Time control both players asked for
 }
 
 type Matchmaker interface {
@@ -0,0 +1,185 @@
+// Package opening names the first moves of a game from a catalogue of
+// known lines, treating rotations and reflections of the board as the same.
+package opening
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+)
+
+//go:embed openings.txt
+var defaultCatalogue string
+
+type Opening struct {
+	Name  string
+	Moves []game.Move // as written in the catalogue
+}
+
+// String returns the line in UGN notation, e.g. "E5 E1".
+func (o Opening) String() string {
+	parts := make([]string, len(o.Moves))
+	for i := range o.Moves {
+		parts[i] = o.Moves[i].ToString()
+	}
+	return strings.Join(parts, " ")
+}
+
+type Catalogue struct {
+	openings []Opening
+	root     *node
+}
+
+type node struct {
+	opening  int // index into openings, -1 if no line ends here
+	children map[game.Move]*node
+}
+
+func newNode() *node {
+	return &node{opening: -1, children: make(map[game.Move]*node)}
+}
+
+// symmetries maps each of the 8 symmetries of a 3x3 grid to a permutation
+// of cell indices. The same permutation applies to board and position, so
+// the send rule is preserved.
+var symmetries = func() [8][9]int {
+	transforms := [8]func(r, c int) (int, int){
+		func(r, c int) (int, int) { return r, c },
+		func(r, c int) (int, int) { return c, 2 - r },
+		func(r, c int) (int, int) { return 2 - r, 2 - c },
+		func(r, c int) (int, int) { return 2 - c, r },
+		func(r, c int) (int, int) { return r, 2 - c },
+		func(r, c int) (int, int) { return 2 - r, c },
+		func(r, c int) (int, int) { return c, r },
+		func(r, c int) (int, int) { return 2 - c, 2 - r },
+	}
+	var perms [8][9]int
+	for s, t := range transforms {
+		for i := 0; i < 9; i++ {
+			r, c := t(i/3, i%3)
+			perms[s][i] = r*3 + c
+		}
+	}
+	return perms
+}()
+
+// Parse reads a catalogue of "Name: moves" lines. Blank lines and lines
+// starting with '#' are ignored. Every line must be legal from the start.
+func Parse(r io.Reader) (*Catalogue, error) {
+	c := &Catalogue{root: newNode()}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, movesText, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("line %d: expected \"Name: moves\"", lineNum)
+		}
+		moves, err := parseLine(movesText)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNum, err)
+		}
+		if err := c.add(Opening{Name: name, Moves: moves}); err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNum, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func parseLine(text string) ([]game.Move, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no moves")
+	}
+	board := game.NewUltimateBoard()
+	moves := make([]game.Move, 0, len(fields))
+	for _, field := range fields {
+		move, err := game.ParseMove(field)
+		if err != nil {
+			return nil, err
+		}
+		if err := board.MakeMove(move.BoardIndex, move.Position); err != nil {
+			return nil, fmt.Errorf("%s: %v", field, err)
+		}
+		moves = append(moves, *move)
+	}
+	return moves, nil
+}
+
+func (c *Catalogue) add(o Opening) error {
+	index := len(c.openings)
+	c.openings = append(c.openings, o)
+	for _, perm := range symmetries {
+		n := c.root
+		for _, m := range o.Moves {
+			m = game.Move{BoardIndex: perm[m.BoardIndex], Position: perm[m.Position]}
+			child, ok := n.children[m]
+			if !ok {
+				child = newNode()
+				n.children[m] = child
+			}
+			n = child
+		}
+		if n.opening == -1 {
+			n.opening = index
+		} else if n.opening != index {
+			return fmt.Errorf("%s is the same line as %s", o.Name, c.openings[n.opening].Name)
+		}
+	}
+	return nil
+}
+
+// Openings returns the catalogue in file order.
+func (c *Catalogue) Openings() []Opening {
+	return c.openings
+}
+
+// Classify returns the deepest catalogued opening that the moves follow,
+// in any orientation.
+func (c *Catalogue) Classify(moves []game.Move) (Opening, bool) {
+	found := -1
+	n := c.root
+	for _, m := range moves {
+		n = n.children[m]
+		if n == nil {
+			break
+		}
+		if n.opening != -1 {
+			found = n.opening
+		}
+	}
+	if found == -1 {
+		return Opening{}, false
+	}
+	return c.openings[found], true
+}
+
+var (
+	defaultOnce sync.Once
+	defaultCat  *Catalogue
+)
+
+// Default returns the catalogue built into the binary.
+func Default() *Catalogue {
+	defaultOnce.Do(func() {
+		var err error
+		defaultCat, err = Parse(strings.NewReader(defaultCatalogue))
+		if err != nil {
+			panic(fmt.Sprintf("opening: bad built-in catalogue: %v", err))
+		}
+	})
+	return defaultCat
+}
@@ -0,0 +1,67 @@
+package opening
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+)
+
+func moves(t *testing.T, s string) []game.Move {
+	t.Helper()
+	var result []game.Move
+	for _, field := range strings.Fields(s) {
+		m, err := game.ParseMove(field)
+		if err != nil {
+			t.Fatalf("ParseMove(%q): %v", field, err)
+		}
+		result = append(result, *m)
+	}
+	return result
+}
+
+func TestDefaultCatalogue(t *testing.T) {
+	if len(Default().Openings()) == 0 {
+		t.Fatal("Built-in catalogue is empty")
+	}
+}
+
+func TestClassify(t *testing.T) {
+	c := Default()
+	tests := []struct {
+		moves string
+		name  string
+	}{
+		{"E5", "Centre Opening"},
+		{"E5 E1 A5 E9", "Centre Opening, Corner Reply, Return"},
+		// Rotations and reflections of E5 E1 A9.
+		{"E5 E3 C7", "Centre Opening, Corner Reply, Mirror"},
+		{"E5 E9 I1", "Centre Opening, Corner Reply, Mirror"},
+		{"I9 I5", "Corner Opening, Centre Reply"},
+		{"D4 D5", "Edge Opening, Centre Reply"},
+		{"E5 E1 A2", "Centre Opening, Corner Reply"},
+	}
+	for _, tt := range tests {
+		o, ok := c.Classify(moves(t, tt.moves))
+		if !ok || o.Name != tt.name {
+			t.Errorf("Classify(%s) = %q, %v; want %q", tt.moves, o.Name, ok, tt.name)
+		}
+	}
+
+	if _, ok := c.Classify(moves(t, "A3 C1")); ok {
+		t.Error("Expected no opening for an uncatalogued line")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	bad := []string{
+		"No colon E5",
+		"Illegal: E5 A1",
+		"Same: A1\nAlso same: I9",
+	}
+	for _, text := range bad {
+		if _, err := Parse(strings.NewReader(text)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", text)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package puzzle
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/bot"
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+	"github.com/eshahhh/ultimatetictactoe/internal/ugn"
+)
+
+const (
+	DefaultMaxMoves = 2
+	SelfPlaySource  = "self-play"
+)
+
+// Miner scans games for puzzle positions, skipping positions it has
+// already turned into a puzzle.
+type Miner struct {
+	MaxMoves int // longest forced win to look for; DefaultMaxMoves when zero
+	solver   Solver
+	seen     map[uint64]bool
+}
+
+func NewMiner(maxMoves, maxNodes int) *Miner {
+	if maxMoves <= 0 {
+		maxMoves = DefaultMaxMoves
+	}
+	return &Miner{
+		MaxMoves: maxMoves,
+		solver:   Solver{MaxNodes: maxNodes},
+		seen:     make(map[uint64]bool),
+	}
+}
+
+func (m *Miner) MineGame(g *ugn.UGNGame) []*Puzzle {
+	moves := make([]game.Move, len(g.Moves))
+	for i, move := range g.Moves {
+		moves[i] = game.Move{BoardIndex: move.BoardIndex, Position: move.Position}
+	}
+	return m.MineMoves(moves, g.Metadata.GameID, g.Metadata.PlayerX, g.Metadata.PlayerO)
+}
+
+// MineMoves returns at most one puzzle per side: the first position in the
+// game where that side had a unique forced win, whether or not it was played.
+func (m *Miner) MineMoves(moves []game.Move, source, playerX, playerO string) []*Puzzle {
+	var puzzles []*Puzzle
+	done := make(map[game.CellState]bool)
+	board := game.NewUltimateBoard()
+	for ply, move := range moves {
+		if board.State != game.Undecided {
+			break
+		}
+		hash := board.Hash()
+		if !done[board.CurrentTurn] && !m.seen[hash] {
+			if line, err := m.solver.Solve(board, m.MaxMoves); err == nil {
+				m.seen[hash] = true
+				done[board.CurrentTurn] = true
+				outcome := OutcomeMissed
+				if move == line[0] {
+					outcome = OutcomeFound
+				}
+				puzzles = append(puzzles, &Puzzle{
+					ID:         fmt.Sprintf("%012x", hash>>16),
+					Setup:      append([]game.Move(nil), moves[:ply]...),
+					Solution:   line,
+					Difficulty: Difficulty(board, (len(line)+1)/2, outcome),
+					Source:     source,
+					Outcome:    outcome,
+					PlayerX:    playerX,
+					PlayerO:    playerO,
+				})
+			}
+		}
+		if err := board.MakeMove(move.BoardIndex, move.Position); err != nil {
+			break
+		}
+	}
+	return puzzles
+}
+
+// SelfPlay plays a game between two search bots. The first randomPlies moves
+// and about one move in five afterwards are random, so games differ and
+// contain the mistakes puzzles are made of.
+func SelfPlay(rng *rand.Rand, depth, randomPlies int) []game.Move {
+	board := game.NewUltimateBoard()
+	var moves []game.Move
+	for board.State == game.Undecided {
+		legal := board.GetValidMoves()
+		if len(legal) == 0 {
+			break
+		}
+		var move game.Move
+		if len(moves) < randomPlies || rng.Intn(5) == 0 {
+			move = legal[rng.Intn(len(legal))]
+		} else {
+			move, _, _ = bot.Search(board, depth)
+		}
+		if err := board.MakeMove(move.BoardIndex, move.Position); err != nil {
+			break
+		}
+		moves = append(moves, move)
+	}
+	return moves
+}
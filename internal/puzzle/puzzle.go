@@ -0,0 +1,227 @@
+// Package puzzle finds, stores and checks tactics puzzles: positions where
+// the side to move has a short, unique forced win.
+package puzzle
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+	"github.com/eshahhh/ultimatetictactoe/internal/ugn"
+)
+
+const (
+	OutcomeFound  = "found"  // the player in the source game played the win
+	OutcomeMissed = "missed" // the player in the source game did not
+)
+
+// Puzzle is a start position, given as the moves leading to it, and the
+// solution line from there. Solution moves alternate between the solver and
+// the forced replies, starting and ending with the solver.
+type Puzzle struct {
+	ID         string
+	Setup      []game.Move
+	Solution   []game.Move
+	Difficulty int
+	Source     string // GameID of the source game, or "self-play"
+	Outcome    string
+	PlayerX    string
+	PlayerO    string
+}
+
+// Board returns the start position.
+func (p *Puzzle) Board() (*game.UltimateBoard, error) {
+	board := game.NewUltimateBoard()
+	for i, m := range p.Setup {
+		if err := board.MakeMove(m.BoardIndex, m.Position); err != nil {
+			return nil, fmt.Errorf("setup move %d (%s): %v", i+1, m.ToString(), err)
+		}
+	}
+	if board.State != game.Undecided {
+		return nil, fmt.Errorf("setup position is already decided")
+	}
+	return board, nil
+}
+
+// Moves is the number of moves the solver has to find.
+func (p *Puzzle) Moves() int {
+	return (len(p.Solution) + 1) / 2
+}
+
+// Validate replays the setup and solution and checks that the solution ends
+// in a win for the side to move.
+func (p *Puzzle) Validate() error {
+	board, err := p.Board()
+	if err != nil {
+		return err
+	}
+	if len(p.Solution)%2 == 0 {
+		return fmt.Errorf("solution must end with the solver's move")
+	}
+	solver := board.CurrentTurn
+	for i, m := range p.Solution {
+		if board.State != game.Undecided {
+			return fmt.Errorf("solution move %d (%s) played after the game ended", i+1, m.ToString())
+		}
+		if err := board.MakeMove(m.BoardIndex, m.Position); err != nil {
+			return fmt.Errorf("solution move %d (%s): %v", i+1, m.ToString(), err)
+		}
+	}
+	if !wins(board, solver) {
+		return fmt.Errorf("solution does not win for %s", solver)
+	}
+	return nil
+}
+
+// Difficulty rates a puzzle on the same scale as player ratings: longer
+// wins, wider choice and wins the original player missed rate higher.
+func Difficulty(board *game.UltimateBoard, moves int, outcome string) int {
+	d := 1000 + 250*(moves-1) + 5*len(board.GetValidMoves())
+	if outcome == OutcomeMissed {
+		d += 150
+	}
+	return d
+}
+
+func movesString(moves []game.Move) string {
+	parts := make([]string, len(moves))
+	for i := range moves {
+		parts[i] = moves[i].ToString()
+	}
+	return strings.Join(parts, " ")
+}
+
+func parseMoves(s string) ([]game.Move, error) {
+	var moves []game.Move
+	for _, field := range strings.Fields(s) {
+		m, err := game.ParseMove(field)
+		if err != nil {
+			return nil, err
+		}
+		moves = append(moves, *m)
+	}
+	return moves, nil
+}
+
+// ToUGN stores the puzzle as a UGN game whose movetext is the setup and
+// whose Solution, Difficulty, Source and Outcome tags describe the puzzle.
+func (p *Puzzle) ToUGN() (*ugn.UGNGame, error) {
+	g, err := ugn.NewGameFromMoves(p.ID, p.PlayerX, p.PlayerO, p.Setup)
+	if err != nil {
+		return nil, err
+	}
+	g.Metadata.SetTag("Solution", movesString(p.Solution))
+	g.Metadata.SetTag("Difficulty", strconv.Itoa(p.Difficulty))
+	if p.Source != "" {
+		g.Metadata.SetTag("Source", p.Source)
+	}
+	if p.Outcome != "" {
+		g.Metadata.SetTag("Outcome", p.Outcome)
+	}
+	return g, nil
+}
+
+func FromUGN(g *ugn.UGNGame) (*Puzzle, error) {
+	p := &Puzzle{
+		ID:      g.Metadata.GameID,
+		PlayerX: g.Metadata.PlayerX,
+		PlayerO: g.Metadata.PlayerO,
+	}
+	for _, m := range g.Moves {
+		p.Setup = append(p.Setup, game.Move{BoardIndex: m.BoardIndex, Position: m.Position})
+	}
+	solution, _ := g.Metadata.Tag("Solution")
+	var err error
+	if p.Solution, err = parseMoves(solution); err != nil {
+		return nil, fmt.Errorf("puzzle %s: bad solution: %v", p.ID, err)
+	}
+	if len(p.Solution) == 0 {
+		return nil, fmt.Errorf("puzzle %s: missing Solution tag", p.ID)
+	}
+	if difficulty, ok := g.Metadata.Tag("Difficulty"); ok && difficulty != "" {
+		if p.Difficulty, err = strconv.Atoi(difficulty); err != nil {
+			return nil, fmt.Errorf("puzzle %s: bad difficulty %q", p.ID, difficulty)
+		}
+	}
+	p.Source, _ = g.Metadata.Tag("Source")
+	p.Outcome, _ = g.Metadata.Tag("Outcome")
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("puzzle %s: %v", p.ID, err)
+	}
+	return p, nil
+}
+
+// Read reads a puzzle file: a multi-game UGN stream with one puzzle per game.
+func Read(r io.Reader) ([]*Puzzle, error) {
+	games, err := ugn.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	puzzles := make([]*Puzzle, 0, len(games))
+	for _, g := range games {
+		p, err := FromUGN(g)
+		if err != nil {
+			return nil, err
+		}
+		puzzles = append(puzzles, p)
+	}
+	return puzzles, nil
+}
+
+func Write(w io.Writer, puzzles []*Puzzle) error {
+	uw := ugn.NewWriter(w)
+	for _, p := range puzzles {
+		g, err := p.ToUGN()
+		if err != nil {
+			return fmt.Errorf("puzzle %s: %v", p.ID, err)
+		}
+		if err := uw.Write(g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Load(path string) ([]*Puzzle, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open puzzle file: %v", err)
+	}
+	defer file.Close()
+	return Read(file)
+}
+
+func Save(path string, puzzles []*Puzzle) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create puzzle file: %v", err)
+	}
+	if err := Write(file, puzzles); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Closest returns the puzzle not in seen whose difficulty is nearest to
+// rating, or nil if every puzzle has been seen.
+func Closest(puzzles []*Puzzle, rating int, seen map[string]bool) *Puzzle {
+	var best *Puzzle
+	bestDistance := 0
+	for _, p := range puzzles {
+		if seen[p.ID] {
+			continue
+		}
+		distance := p.Difficulty - rating
+		if distance < 0 {
+			distance = -distance
+		}
+		if best == nil || distance < bestDistance {
+			best, bestDistance = p, distance
+		}
+	}
+	return best
+}
@@ -0,0 +1,81 @@
+package puzzle
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+)
+
+func TestSolverFindsUniqueWin(t *testing.T) {
+	board := game.NewUltimateBoard()
+	board.CurrentTurn = game.O
+	// O holds boards E and I, so winning board A completes the diagonal;
+	// O has A1 and A5 and is sent to A.
+	for _, i := range []int{0, 3, 6} {
+		board.Boards[4].MakeMove(i, game.O)
+		board.Boards[8].MakeMove(i, game.O)
+	}
+	board.Boards[0].MakeMove(0, game.O)
+	board.Boards[0].MakeMove(4, game.O)
+	board.ActiveBoard = 0
+
+	var s Solver
+	line, err := s.Solve(board, 2)
+	if err != nil {
+		t.Fatalf("Solve failed: %v", err)
+	}
+	if len(line) != 1 || line[0] != (game.Move{BoardIndex: 0, Position: 8}) {
+		t.Errorf("Expected A9, got %v", line)
+	}
+
+	// Holding C too, O could instead win G for the other diagonal.
+	for _, i := range []int{1, 4, 7} {
+		board.Boards[2].MakeMove(i, game.O)
+	}
+	board.Boards[6].MakeMove(2, game.O)
+	board.Boards[6].MakeMove(4, game.O)
+	board.ActiveBoard = -1
+	if _, err := s.Solve(board, 2); err != ErrNotUnique {
+		t.Errorf("Expected ErrNotUnique, got %v", err)
+	}
+
+	if _, err := s.Solve(game.NewUltimateBoard(), 1); err != ErrNoWin {
+		t.Errorf("Expected ErrNoWin from the start position, got %v", err)
+	}
+}
+
+func TestMineAndRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	miner := NewMiner(2, 0)
+	var puzzles []*Puzzle
+	for i := 0; i < 10 && len(puzzles) == 0; i++ {
+		puzzles = append(puzzles, miner.MineMoves(SelfPlay(rng, 1, 6), SelfPlaySource, "bot", "bot")...)
+	}
+	if len(puzzles) == 0 {
+		t.Fatal("Expected self-play to produce a puzzle")
+	}
+	for _, p := range puzzles {
+		if err := p.Validate(); err != nil {
+			t.Errorf("Mined puzzle %s is invalid: %v", p.ID, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, puzzles); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	read, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(read) != len(puzzles) {
+		t.Fatalf("Expected %d puzzles, got %d", len(puzzles), len(read))
+	}
+	got, want := read[0], puzzles[0]
+	if got.ID != want.ID || movesString(got.Solution) != movesString(want.Solution) ||
+		len(got.Setup) != len(want.Setup) || got.Difficulty != want.Difficulty || got.Outcome != want.Outcome {
+		t.Errorf("Round trip changed the puzzle: %+v vs %+v", got, want)
+	}
+}
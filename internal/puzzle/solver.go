@@ -0,0 +1,134 @@
+package puzzle
+
+import (
+	"errors"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+)
+
+const DefaultMaxNodes = 200000
+
+var (
+	ErrNoWin     = errors.New("no forced win")
+	ErrNotUnique = errors.New("more than one winning move")
+	ErrBudget    = errors.New("search budget exhausted")
+)
+
+// Solver looks for forced wins: positions where the side to move wins the
+// whole game within n of its own moves whatever the opponent replies.
+type Solver struct {
+	MaxNodes int // per Solve call; DefaultMaxNodes when zero
+	nodes    int
+}
+
+func (s *Solver) exhausted() bool {
+	limit := s.MaxNodes
+	if limit <= 0 {
+		limit = DefaultMaxNodes
+	}
+	return s.nodes > limit
+}
+
+func wins(board *game.UltimateBoard, player game.CellState) bool {
+	return (player == game.X && board.State == game.XWins) || (player == game.O && board.State == game.OWins)
+}
+
+func play(board *game.UltimateBoard, m game.Move) *game.UltimateBoard {
+	next := board.Clone()
+	next.MakeMove(m.BoardIndex, m.Position)
+	return next
+}
+
+// winIn reports whether the side to move can force a win within n moves.
+func (s *Solver) winIn(board *game.UltimateBoard, n int) bool {
+	if n <= 0 || board.State != game.Undecided {
+		return false
+	}
+	for _, m := range board.GetValidMoves() {
+		if s.moveWins(board, m, n) {
+			return true
+		}
+		if s.exhausted() {
+			return false
+		}
+	}
+	return false
+}
+
+// moveWins reports whether m forces a win within n moves, counting m.
+func (s *Solver) moveWins(board *game.UltimateBoard, m game.Move, n int) bool {
+	s.nodes++
+	player := board.CurrentTurn
+	after := play(board, m)
+	if wins(after, player) {
+		return true
+	}
+	if n == 1 || after.State != game.Undecided {
+		return false
+	}
+	for _, reply := range after.GetValidMoves() {
+		s.nodes++
+		if !s.winIn(play(after, reply), n-1) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Solver) winningMoves(board *game.UltimateBoard, n int) []game.Move {
+	var winning []game.Move
+	for _, m := range board.GetValidMoves() {
+		if s.moveWins(board, m, n) {
+			winning = append(winning, m)
+		}
+	}
+	return winning
+}
+
+// Solve finds the shortest forced win of at most maxMoves moves for the side
+// to move and returns the full line, alternating winning moves and the
+// opponent's most stubborn replies. Every winning move in the line must be
+// the only one that wins in time, otherwise ErrNotUnique is returned.
+func (s *Solver) Solve(board *game.UltimateBoard, maxMoves int) ([]game.Move, error) {
+	s.nodes = 0
+	for n := 1; n <= maxMoves; n++ {
+		if s.winIn(board, n) {
+			return s.line(board, n)
+		}
+		if s.exhausted() {
+			return nil, ErrBudget
+		}
+	}
+	return nil, ErrNoWin
+}
+
+func (s *Solver) line(board *game.UltimateBoard, n int) ([]game.Move, error) {
+	var line []game.Move
+	for ; n > 0; n-- {
+		winning := s.winningMoves(board, n)
+		if s.exhausted() {
+			return nil, ErrBudget
+		}
+		if len(winning) != 1 {
+			return nil, ErrNotUnique
+		}
+		line = append(line, winning[0])
+		board = play(board, winning[0])
+		if board.State != game.Undecided {
+			return line, nil
+		}
+
+		// Pick a reply that holds out for all n-1 remaining moves.
+		replies := board.GetValidMoves()
+		reply := replies[0]
+		for _, r := range replies {
+			if !s.winIn(play(board, r), n-2) {
+				reply = r
+				break
+			}
+		}
+		line = append(line, reply)
+		board = play(board, reply)
+	}
+	return line, nil
+}
@@ -0,0 +1,86 @@
+package rating
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+)
+
+const DefaultRating = 1500
+
+// Table maps player names to ratings and is stored as a flat JSON object.
+type Table struct {
+	ratings map[string]int
+	mutex   sync.RWMutex
+}
+
+func NewTable() *Table {
+	return &Table{
+		ratings: make(map[string]int),
+	}
+}
+
+func Load(filename string) (*Table, error) {
+	table := NewTable()
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return table, nil
+		}
+		return nil, fmt.Errorf("failed to read ratings file: %v", err)
+	}
+	if err := json.Unmarshal(data, &table.ratings); err != nil {
+		return nil, fmt.Errorf("failed to parse ratings file: %v", err)
+	}
+	return table, nil
+}
+
+func (t *Table) Save(filename string) error {
+	t.mutex.RLock()
+	data, err := json.MarshalIndent(t.ratings, "", "  ")
+	t.mutex.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode ratings: %v", err)
+	}
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("failed to write ratings file: %v", err)
+	}
+	return nil
+}
+
+func (t *Table) Lookup(name string) (int, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	r, ok := t.ratings[name]
+	return r, ok
+}
+
+func (t *Table) Get(name string) int {
+	if r, ok := t.Lookup(name); ok {
+		return r
+	}
+	return DefaultRating
+}
+
+func (t *Table) Set(name string, r int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.ratings[name] = r
+}
+
+// KFactor is the largest rating change a single result can cause.
+const KFactor = 32
+
+// Expected is the score a player rated r is expected to make against an
+// opponent rated opponent, between 0 and 1.
+func Expected(r, opponent int) float64 {
+	return 1 / (1 + math.Pow(10, float64(opponent-r)/400))
+}
+
+// Update returns the new Elo rating after scoring score (1 win, 0.5 draw,
+// 0 loss) against opponent.
+func Update(r, opponent int, score float64) int {
+	return r + int(math.Round(KFactor*(score-Expected(r, opponent))))
+}
@@ -0,0 +1,82 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+	"time"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+)
+
+const (
+	DefaultDelay      = 800 * time.Millisecond
+	DefaultFinalDelay = 3 * time.Second
+)
+
+type GIFOptions struct {
+	Size       int
+	Delay      time.Duration // between moves; DefaultDelay when zero
+	FinalDelay time.Duration // on the last frame; DefaultFinalDelay when zero
+}
+
+// GIF replays moves from the empty board and writes one frame per position,
+// marking the move just played. Won boards are washed out from the frame in
+// which they fall.
+func GIF(w io.Writer, moves []game.Move, opts GIFOptions) error {
+	delay := opts.Delay
+	if delay <= 0 {
+		delay = DefaultDelay
+	}
+	finalDelay := opts.FinalDelay
+	if finalDelay <= 0 {
+		finalDelay = DefaultFinalDelay
+	}
+
+	pal := gifPalette()
+	anim := &gif.GIF{}
+	board := game.NewUltimateBoard()
+	addFrame := func(last *game.Move) {
+		img := Image(board, Options{Size: opts.Size, LastMove: last})
+		frame := image.NewPaletted(img.Bounds(), pal)
+		draw.Draw(frame, img.Bounds(), img, image.Point{}, draw.Src)
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, centiseconds(delay))
+	}
+
+	addFrame(nil)
+	for i := range moves {
+		if err := board.MakeMove(moves[i].BoardIndex, moves[i].Position); err != nil {
+			return fmt.Errorf("move %d (%s): %v", i+1, moves[i].ToString(), err)
+		}
+		addFrame(&moves[i])
+	}
+	anim.Delay[len(anim.Delay)-1] = centiseconds(finalDelay)
+
+	return gif.EncodeAll(w, anim)
+}
+
+func centiseconds(d time.Duration) int {
+	return int(d / (10 * time.Millisecond))
+}
+
+// gifPalette holds every colour the renderer draws, both plain and under the
+// won-board overlay, so frames convert without dithering.
+func gifPalette() color.Palette {
+	base := []color.RGBA{
+		backgroundColor, activeColor, lastMoveColor, minorLineColor,
+		majorLineColor, xColor, oColor, drawColor,
+	}
+	pal := make(color.Palette, 0, 2*len(base))
+	pixel := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	for _, c := range base {
+		pal = append(pal, c)
+		pixel.SetRGBA(0, 0, c)
+		draw.Draw(pixel, pixel.Bounds(), image.NewUniform(overlayColor), image.Point{}, draw.Over)
+		pal = append(pal, pixel.RGBAAt(0, 0))
+	}
+	return pal
+}
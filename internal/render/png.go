@@ -0,0 +1,133 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+)
+
+// PNG writes the board as a PNG image.
+func PNG(w io.Writer, board *game.UltimateBoard, opts Options) error {
+	return png.Encode(w, Image(board, opts))
+}
+
+// Image draws the board onto a new RGBA image.
+func Image(board *game.UltimateBoard, opts Options) *image.RGBA {
+	l := newLayout(opts.Size)
+	img := image.NewRGBA(image.Rect(0, 0, int(l.size), int(l.size)))
+	fillRect(img, 0, 0, l.size, l.size, backgroundColor)
+
+	playable := playableBoards(board)
+	for b := 0; b < 9; b++ {
+		if playable[b] {
+			x, y := l.boardOrigin(b)
+			fillRect(img, x, y, l.big, l.big, activeColor)
+		}
+	}
+	if m := opts.LastMove; m != nil {
+		x, y := l.cellOrigin(m.BoardIndex, m.Position)
+		fillRect(img, x, y, l.cell, l.cell, lastMoveColor)
+	}
+
+	for b := 0; b < 9; b++ {
+		x, y := l.boardOrigin(b)
+		for i := 1; i < 3; i++ {
+			off := l.inset + float64(i)*l.cell
+			strokeLine(img, x+off, y+l.inset, x+off, y+l.big-l.inset, l.minorWidth(), minorLineColor)
+			strokeLine(img, x+l.inset, y+off, x+l.big-l.inset, y+off, l.minorWidth(), minorLineColor)
+		}
+		for p, cell := range board.Boards[b].Cells {
+			cx, cy := l.cellOrigin(b, p)
+			drawMark(img, cell, cx, cy, l.cell, l.markWidth())
+		}
+	}
+
+	for b, sb := range board.Boards {
+		if sb.State == game.Undecided {
+			continue
+		}
+		x, y := l.boardOrigin(b)
+		r := image.Rect(int(x), int(y), int(x+l.big), int(y+l.big))
+		draw.Draw(img, r, image.NewUniform(overlayColor), image.Point{}, draw.Over)
+		switch sb.State {
+		case game.XWins:
+			drawMark(img, game.X, x, y, l.big, l.markWidth()*2)
+		case game.OWins:
+			drawMark(img, game.O, x, y, l.big, l.markWidth()*2)
+		case game.Draw:
+			strokeLine(img, x+l.big*0.25, y+l.big/2, x+l.big*0.75, y+l.big/2, l.markWidth()*2, drawColor)
+		}
+	}
+
+	for i := 1; i < 3; i++ {
+		off := l.pad + float64(i)*l.big
+		strokeLine(img, off, l.pad, off, l.size-l.pad, l.majorWidth(), majorLineColor)
+		strokeLine(img, l.pad, off, l.size-l.pad, off, l.majorWidth(), majorLineColor)
+	}
+
+	return img
+}
+
+func drawMark(img *image.RGBA, mark game.CellState, x, y, side, width float64) {
+	m := side * 0.2
+	switch mark {
+	case game.X:
+		strokeLine(img, x+m, y+m, x+side-m, y+side-m, width, xColor)
+		strokeLine(img, x+side-m, y+m, x+m, y+side-m, width, xColor)
+	case game.O:
+		strokeCircle(img, x+side/2, y+side/2, side/2-m, width, oColor)
+	}
+}
+
+func fillRect(img *image.RGBA, x, y, width, height float64, c color.RGBA) {
+	r := image.Rect(int(math.Round(x)), int(math.Round(y)), int(math.Round(x+width)), int(math.Round(y+height)))
+	draw.Draw(img, r, image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+// strokeLine paints every pixel whose centre lies within width/2 of the
+// segment, which gives round caps for free.
+func strokeLine(img *image.RGBA, x1, y1, x2, y2, width float64, c color.RGBA) {
+	half := width / 2
+	bounds := image.Rect(
+		int(math.Floor(min(x1, x2)-half)), int(math.Floor(min(y1, y2)-half)),
+		int(math.Ceil(max(x1, x2)+half)), int(math.Ceil(max(y1, y2)+half)),
+	).Intersect(img.Bounds())
+
+	dx, dy := x2-x1, y2-y1
+	length2 := dx*dx + dy*dy
+	for py := bounds.Min.Y; py < bounds.Max.Y; py++ {
+		for px := bounds.Min.X; px < bounds.Max.X; px++ {
+			cx, cy := float64(px)+0.5, float64(py)+0.5
+			t := 0.0
+			if length2 > 0 {
+				t = math.Max(0, math.Min(1, ((cx-x1)*dx+(cy-y1)*dy)/length2))
+			}
+			if math.Hypot(cx-(x1+t*dx), cy-(y1+t*dy)) <= half {
+				img.SetRGBA(px, py, c)
+			}
+		}
+	}
+}
+
+func strokeCircle(img *image.RGBA, cx, cy, radius, width float64, c color.RGBA) {
+	half := width / 2
+	outer := radius + half
+	bounds := image.Rect(
+		int(math.Floor(cx-outer)), int(math.Floor(cy-outer)),
+		int(math.Ceil(cx+outer)), int(math.Ceil(cy+outer)),
+	).Intersect(img.Bounds())
+
+	for py := bounds.Min.Y; py < bounds.Max.Y; py++ {
+		for px := bounds.Min.X; px < bounds.Max.X; px++ {
+			d := math.Hypot(float64(px)+0.5-cx, float64(py)+0.5-cy)
+			if math.Abs(d-radius) <= half {
+				img.SetRGBA(px, py, c)
+			}
+		}
+	}
+}
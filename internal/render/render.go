@@ -0,0 +1,81 @@
+// Package render draws an UltimateBoard as an SVG or raster diagram.
+package render
+
+import (
+	"image/color"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+)
+
+const DefaultSize = 450
+
+type Options struct {
+	Size     int        // width and height in pixels; DefaultSize when zero
+	LastMove *game.Move // marked cell, if any
+}
+
+var (
+	backgroundColor = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	activeColor     = color.RGBA{0xff, 0xf4, 0xc2, 0xff}
+	lastMoveColor   = color.RGBA{0xc8, 0xf0, 0xc8, 0xff}
+	minorLineColor  = color.RGBA{0xb0, 0xb0, 0xb0, 0xff}
+	majorLineColor  = color.RGBA{0x30, 0x30, 0x30, 0xff}
+	xColor          = color.RGBA{0xd0, 0x30, 0x30, 0xff}
+	oColor          = color.RGBA{0x30, 0x60, 0xd0, 0xff}
+	drawColor       = color.RGBA{0x80, 0x80, 0x80, 0xff}
+
+	// Won and drawn boards are washed out before the big mark is drawn.
+	overlayColor = color.NRGBA{0xff, 0xff, 0xff, 0xb0}
+)
+
+// layout holds the geometry shared by the SVG and raster renderers.
+type layout struct {
+	size  float64
+	pad   float64 // margin around the whole board
+	big   float64 // side of one small board
+	inset float64 // gap between a small board's edge and its cells
+	cell  float64
+}
+
+func newLayout(size int) layout {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	l := layout{size: float64(size)}
+	l.pad = l.size * 0.02
+	l.big = (l.size - 2*l.pad) / 3
+	l.inset = l.big * 0.06
+	l.cell = (l.big - 2*l.inset) / 3
+	return l
+}
+
+// boardOrigin is the top-left corner of small board b.
+func (l layout) boardOrigin(b int) (float64, float64) {
+	return l.pad + float64(b%3)*l.big, l.pad + float64(b/3)*l.big
+}
+
+// cellOrigin is the top-left corner of cell p on small board b.
+func (l layout) cellOrigin(b, p int) (float64, float64) {
+	x, y := l.boardOrigin(b)
+	return x + l.inset + float64(p%3)*l.cell, y + l.inset + float64(p/3)*l.cell
+}
+
+func (l layout) minorWidth() float64 { return max(1, l.size/300) }
+func (l layout) majorWidth() float64 { return max(2, l.size/110) }
+func (l layout) markWidth() float64  { return max(1.5, l.cell/9) }
+
+// playableBoards reports which small boards accept the next move.
+func playableBoards(board *game.UltimateBoard) [9]bool {
+	var playable [9]bool
+	if board.State != game.Undecided {
+		return playable
+	}
+	if board.ActiveBoard != -1 && board.Boards[board.ActiveBoard].State == game.Undecided {
+		playable[board.ActiveBoard] = true
+		return playable
+	}
+	for i, sb := range board.Boards {
+		playable[i] = sb.State == game.Undecided
+	}
+	return playable
+}
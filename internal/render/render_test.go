@@ -0,0 +1,86 @@
+package render
+
+import (
+	"bytes"
+	"image/gif"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+)
+
+func sampleBoard() (*game.UltimateBoard, *game.Move) {
+	board := game.NewUltimateBoard()
+	for i := 0; i < 3; i++ {
+		board.Boards[0].MakeMove(i, game.X)
+	}
+	board.MakeMove(4, 4)
+	return board, &game.Move{BoardIndex: 4, Position: 4}
+}
+
+func TestSVG(t *testing.T) {
+	board, last := sampleBoard()
+	var buf bytes.Buffer
+	if err := SVG(&buf, board, Options{Size: 300, LastMove: last}); err != nil {
+		t.Fatalf("SVG failed: %v", err)
+	}
+	svg := buf.String()
+	if !strings.HasPrefix(svg, "<svg") || !strings.HasSuffix(svg, "</svg>\n") {
+		t.Errorf("Not an SVG document: %q", svg[:40])
+	}
+	for _, want := range []string{svgColor(lastMoveColor), svgColor(activeColor), `fill-opacity=`} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("SVG missing %q", want)
+		}
+	}
+}
+
+func TestPNG(t *testing.T) {
+	board, last := sampleBoard()
+	var buf bytes.Buffer
+	if err := PNG(&buf, board, Options{Size: 180, LastMove: last}); err != nil {
+		t.Fatalf("PNG failed: %v", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if img.Bounds().Dx() != 180 || img.Bounds().Dy() != 180 {
+		t.Errorf("Expected 180x180, got %v", img.Bounds())
+	}
+
+	// E5 is active for O, so its corner is highlighted.
+	l := newLayout(180)
+	x, y := l.boardOrigin(4)
+	r, g, b, _ := img.At(int(x)+2, int(y)+2).RGBA()
+	ar, ag, ab, _ := activeColor.RGBA()
+	if r != ar || g != ag || b != ab {
+		t.Errorf("Active board not highlighted")
+	}
+}
+
+func TestGIF(t *testing.T) {
+	moves := []game.Move{{BoardIndex: 4, Position: 4}, {BoardIndex: 4, Position: 0}, {BoardIndex: 0, Position: 4}}
+	var buf bytes.Buffer
+	err := GIF(&buf, moves, GIFOptions{Size: 90, Delay: 500 * time.Millisecond, FinalDelay: 2 * time.Second})
+	if err != nil {
+		t.Fatalf("GIF failed: %v", err)
+	}
+	anim, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("DecodeAll failed: %v", err)
+	}
+	if len(anim.Image) != len(moves)+1 {
+		t.Fatalf("Expected %d frames, got %d", len(moves)+1, len(anim.Image))
+	}
+	if anim.Delay[0] != 50 || anim.Delay[len(moves)] != 200 {
+		t.Errorf("Unexpected delays %v", anim.Delay)
+	}
+
+	illegal := []game.Move{{BoardIndex: 4, Position: 4}, {BoardIndex: 0, Position: 0}}
+	if err := GIF(&bytes.Buffer{}, illegal, GIFOptions{}); err == nil {
+		t.Error("Expected an error for an illegal move")
+	}
+}
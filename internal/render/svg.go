@@ -0,0 +1,98 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+)
+
+// SVG writes the board as a standalone SVG document.
+func SVG(w io.Writer, board *game.UltimateBoard, opts Options) error {
+	l := newLayout(opts.Size)
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" viewBox="0 0 %g %g">`+"\n",
+		l.size, l.size, l.size, l.size)
+	svgRect(bw, 0, 0, l.size, l.size, backgroundColor, "")
+
+	playable := playableBoards(board)
+	for b := 0; b < 9; b++ {
+		if playable[b] {
+			x, y := l.boardOrigin(b)
+			svgRect(bw, x, y, l.big, l.big, activeColor, "")
+		}
+	}
+	if m := opts.LastMove; m != nil {
+		x, y := l.cellOrigin(m.BoardIndex, m.Position)
+		svgRect(bw, x, y, l.cell, l.cell, lastMoveColor, "")
+	}
+
+	for b := 0; b < 9; b++ {
+		x, y := l.boardOrigin(b)
+		for i := 1; i < 3; i++ {
+			off := l.inset + float64(i)*l.cell
+			svgLine(bw, x+off, y+l.inset, x+off, y+l.big-l.inset, minorLineColor, l.minorWidth())
+			svgLine(bw, x+l.inset, y+off, x+l.big-l.inset, y+off, minorLineColor, l.minorWidth())
+		}
+		for p, cell := range board.Boards[b].Cells {
+			cx, cy := l.cellOrigin(b, p)
+			svgMark(bw, cell, cx, cy, l.cell, l.markWidth())
+		}
+	}
+
+	for b, sb := range board.Boards {
+		if sb.State == game.Undecided {
+			continue
+		}
+		x, y := l.boardOrigin(b)
+		svgRect(bw, x, y, l.big, l.big, color.RGBA{overlayColor.R, overlayColor.G, overlayColor.B, 0xff},
+			fmt.Sprintf(` fill-opacity="%.2f"`, float64(overlayColor.A)/0xff))
+		switch sb.State {
+		case game.XWins:
+			svgMark(bw, game.X, x, y, l.big, l.markWidth()*2)
+		case game.OWins:
+			svgMark(bw, game.O, x, y, l.big, l.markWidth()*2)
+		case game.Draw:
+			svgLine(bw, x+l.big*0.25, y+l.big/2, x+l.big*0.75, y+l.big/2, drawColor, l.markWidth()*2)
+		}
+	}
+
+	for i := 1; i < 3; i++ {
+		off := l.pad + float64(i)*l.big
+		svgLine(bw, off, l.pad, off, l.size-l.pad, majorLineColor, l.majorWidth())
+		svgLine(bw, l.pad, off, l.size-l.pad, off, majorLineColor, l.majorWidth())
+	}
+
+	fmt.Fprintln(bw, `</svg>`)
+	return bw.Flush()
+}
+
+func svgColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func svgRect(w io.Writer, x, y, width, height float64, c color.RGBA, extra string) {
+	fmt.Fprintf(w, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"%s/>`+"\n",
+		x, y, width, height, svgColor(c), extra)
+}
+
+func svgLine(w io.Writer, x1, y1, x2, y2 float64, c color.RGBA, width float64) {
+	fmt.Fprintf(w, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="%.1f" stroke-linecap="round"/>`+"\n",
+		x1, y1, x2, y2, svgColor(c), width)
+}
+
+// svgMark draws an X or O inside the square at (x, y) with the given side.
+func svgMark(w io.Writer, mark game.CellState, x, y, side, width float64) {
+	m := side * 0.2
+	switch mark {
+	case game.X:
+		svgLine(w, x+m, y+m, x+side-m, y+side-m, xColor, width)
+		svgLine(w, x+side-m, y+m, x+m, y+side-m, xColor, width)
+	case game.O:
+		fmt.Fprintf(w, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="none" stroke="%s" stroke-width="%.1f"/>`+"\n",
+			x+side/2, y+side/2, side/2-m, svgColor(oColor), width)
+	}
+}
@@ -0,0 +1,281 @@
+package ugn
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+)
+
+// Format names a move notation used by other ultimate tic-tac-toe sites and
+// bot competitions.
+type Format string
+
+const (
+	FormatUGN       Format = "ugn"
+	FormatRowCol    Format = "rowcol"    // "row col" per move on the 9x9 grid, 0-8 each
+	FormatIndex     Format = "index"     // 0-80 row-major cell index on the 9x9 grid
+	FormatBoardCell Format = "boardcell" // 0-80 as board*9 + cell, boards and cells in reading order
+	FormatJSON      Format = "json"
+)
+
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(name)); f {
+	case FormatUGN, FormatRowCol, FormatIndex, FormatBoardCell, FormatJSON:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown format %q (expected ugn, rowcol, index, boardcell or json)", name)
+}
+
+// ToRowCol converts a move to its row and column on the 9x9 grid.
+func ToRowCol(move game.Move) (row, col int) {
+	row = move.BoardIndex/3*3 + move.Position/3
+	col = move.BoardIndex%3*3 + move.Position%3
+	return row, col
+}
+
+func FromRowCol(row, col int) (game.Move, error) {
+	if row < 0 || row > 8 || col < 0 || col > 8 {
+		return game.Move{}, fmt.Errorf("row and column must be 0-8, got %d %d", row, col)
+	}
+	return game.Move{
+		BoardIndex: row/3*3 + col/3,
+		Position:   row%3*3 + col%3,
+	}, nil
+}
+
+func ToIndex(move game.Move) int {
+	row, col := ToRowCol(move)
+	return row*9 + col
+}
+
+func FromIndex(index int) (game.Move, error) {
+	if index < 0 || index > 80 {
+		return game.Move{}, fmt.Errorf("cell index must be 0-80, got %d", index)
+	}
+	return FromRowCol(index/9, index%9)
+}
+
+func ToBoardCell(move game.Move) int {
+	return move.BoardIndex*9 + move.Position
+}
+
+func FromBoardCell(index int) (game.Move, error) {
+	if index < 0 || index > 80 {
+		return game.Move{}, fmt.Errorf("cell index must be 0-80, got %d", index)
+	}
+	return game.Move{BoardIndex: index / 9, Position: index % 9}, nil
+}
+
+// FormatMoves writes the main line in one of the plain move formats: one
+// "row col" pair per line for rowcol, space-separated numbers for index and
+// boardcell.
+func FormatMoves(g *UGNGame, format Format) (string, error) {
+	parts := make([]string, 0, len(g.Moves))
+	for _, m := range g.Moves {
+		move := game.Move{BoardIndex: m.BoardIndex, Position: m.Position}
+		switch format {
+		case FormatRowCol:
+			row, col := ToRowCol(move)
+			parts = append(parts, fmt.Sprintf("%d %d", row, col))
+		case FormatIndex:
+			parts = append(parts, strconv.Itoa(ToIndex(move)))
+		case FormatBoardCell:
+			parts = append(parts, strconv.Itoa(ToBoardCell(move)))
+		default:
+			return "", fmt.Errorf("format %s is not a plain move format", format)
+		}
+	}
+	if format == FormatRowCol {
+		return strings.Join(parts, "\n"), nil
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// ParseMoves reads moves in one of the plain move formats. Numbers may be
+// separated by whitespace or commas.
+func ParseMoves(text string, format Format) ([]game.Move, error) {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	numbers := make([]int, len(fields))
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", field)
+		}
+		numbers[i] = n
+	}
+
+	var moves []game.Move
+	switch format {
+	case FormatRowCol:
+		if len(numbers)%2 != 0 {
+			return nil, fmt.Errorf("rowcol input needs pairs of numbers, got %d numbers", len(numbers))
+		}
+		for i := 0; i < len(numbers); i += 2 {
+			move, err := FromRowCol(numbers[i], numbers[i+1])
+			if err != nil {
+				return nil, fmt.Errorf("move %d: %v", i/2+1, err)
+			}
+			moves = append(moves, move)
+		}
+	case FormatIndex, FormatBoardCell:
+		from := FromIndex
+		if format == FormatBoardCell {
+			from = FromBoardCell
+		}
+		for i, n := range numbers {
+			move, err := from(n)
+			if err != nil {
+				return nil, fmt.Errorf("move %d: %v", i+1, err)
+			}
+			moves = append(moves, move)
+		}
+	default:
+		return nil, fmt.Errorf("format %s is not a plain move format", format)
+	}
+	return moves, nil
+}
+
+// NewGameFromMoves replays moves from the starting position and returns a
+// game with UGN annotations filled in. If the moves finish the game the
+// result is set from the final position.
+func NewGameFromMoves(gameID, playerX, playerO string, moves []game.Move) (*UGNGame, error) {
+	g := NewUGNGame(gameID, playerX, playerO)
+	g.SetResult("*")
+	board := game.NewUltimateBoard()
+	for i, move := range moves {
+		if board.State != game.Undecided {
+			return nil, fmt.Errorf("move %d (%s) played after the game ended", i+1, move.ToString())
+		}
+		beforeGameState := board.State
+		beforeSmallState := board.Boards[move.BoardIndex].State
+		if err := board.MakeMove(move.BoardIndex, move.Position); err != nil {
+			return nil, fmt.Errorf("move %d (%s): %v", i+1, move.ToString(), err)
+		}
+		g.AddMove(*GenerateUGNMove(&move, board, beforeGameState, beforeSmallState))
+	}
+	if result := boardResult(board.State); result != "" {
+		g.SetResult(result)
+	}
+	return g, nil
+}
+
+type JSONGame struct {
+	GameID      string     `json:"game_id"`
+	Date        string     `json:"date,omitempty"`
+	Time        string     `json:"time,omitempty"`
+	PlayerX     string     `json:"player_x"`
+	PlayerO     string     `json:"player_o"`
+	Result      string     `json:"result"`
+	Comment     string     `json:"comment,omitempty"`
+	TimeControl string     `json:"time_control,omitempty"`
+	Tags        []JSONTag  `json:"tags,omitempty"`
+	Moves       []JSONMove `json:"moves"`
+}
+
+type JSONTag struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type JSONMove struct {
+	Move      string `json:"move"`  // UGN notation with annotations, e.g. "E9!"
+	Board     int    `json:"board"` // 0-8
+	Cell      int    `json:"cell"`  // 0-8
+	Row       int    `json:"row"`   // 0-8 on the 9x9 grid
+	Col       int    `json:"col"`   // 0-8 on the 9x9 grid
+	Index     int    `json:"index"` // 0-80 row-major
+	ElapsedMs int64  `json:"elapsed_ms,omitempty"`
+	ClockMs   int64  `json:"clock_ms,omitempty"`
+	Comment   string `json:"comment,omitempty"`
+}
+
+func (g *UGNGame) ToJSON() JSONGame {
+	jg := JSONGame{
+		GameID:      g.Metadata.GameID,
+		Date:        g.Metadata.Date,
+		Time:        g.Metadata.Time,
+		PlayerX:     g.Metadata.PlayerX,
+		PlayerO:     g.Metadata.PlayerO,
+		Result:      g.Metadata.Result,
+		Comment:     g.Metadata.Comment,
+		TimeControl: g.Metadata.TimeControl,
+		Moves:       make([]JSONMove, 0, len(g.Moves)),
+	}
+	for _, tag := range g.Metadata.Extra {
+		jg.Tags = append(jg.Tags, JSONTag{Name: tag.Name, Value: tag.Value})
+	}
+	for _, m := range g.Moves {
+		move := game.Move{BoardIndex: m.BoardIndex, Position: m.Position}
+		row, col := ToRowCol(move)
+		jg.Moves = append(jg.Moves, JSONMove{
+			Move:      m.ToString(),
+			Board:     m.BoardIndex,
+			Cell:      m.Position,
+			Row:       row,
+			Col:       col,
+			Index:     ToIndex(move),
+			ElapsedMs: m.Elapsed.Milliseconds(),
+			ClockMs:   m.Clock.Milliseconds(),
+			Comment:   m.Comment,
+		})
+	}
+	return jg
+}
+
+// FromJSON rebuilds a game from its JSON form. Each move is read from its
+// "move" field; the numeric fields are informational.
+func FromJSON(jg JSONGame) (*UGNGame, error) {
+	g := &UGNGame{
+		Metadata: GameMetadata{
+			GameID:      jg.GameID,
+			Date:        jg.Date,
+			Time:        jg.Time,
+			PlayerX:     jg.PlayerX,
+			PlayerO:     jg.PlayerO,
+			Result:      jg.Result,
+			Comment:     jg.Comment,
+			TimeControl: jg.TimeControl,
+		},
+	}
+	for _, tag := range jg.Tags {
+		g.Metadata.Extra.Set(tag.Name, tag.Value)
+	}
+	for i, jm := range jg.Moves {
+		m, err := ParseMove(jm.Move)
+		if err != nil {
+			return nil, fmt.Errorf("move %d: %v", i+1, err)
+		}
+		m.Elapsed = time.Duration(jm.ElapsedMs) * time.Millisecond
+		m.Clock = time.Duration(jm.ClockMs) * time.Millisecond
+		m.Comment = jm.Comment
+		g.AddMove(*m)
+	}
+	return g, nil
+}
+
+// DecodeJSONGames accepts a single JSON game or an array of games.
+func DecodeJSONGames(data []byte) ([]*UGNGame, error) {
+	var many []JSONGame
+	if err := json.Unmarshal(data, &many); err != nil {
+		var one JSONGame
+		if err := json.Unmarshal(data, &one); err != nil {
+			return nil, fmt.Errorf("invalid JSON game: %v", err)
+		}
+		many = []JSONGame{one}
+	}
+	games := make([]*UGNGame, 0, len(many))
+	for i, jg := range many {
+		g, err := FromJSON(jg)
+		if err != nil {
+			return nil, fmt.Errorf("game %d: %v", i+1, err)
+		}
+		games = append(games, g)
+	}
+	return games, nil
+}
@@ -0,0 +1,108 @@
+package ugn
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+)
+
+func TestCoordinateConversions(t *testing.T) {
+	tests := []struct {
+		move      string
+		row, col  int
+		index     int
+		boardCell int
+	}{
+		{"A1", 0, 0, 0, 0},
+		{"A9", 2, 2, 20, 8},
+		{"C3", 0, 8, 8, 20},
+		{"E5", 4, 4, 40, 40},
+		{"F4", 4, 6, 42, 48},
+		{"I9", 8, 8, 80, 80},
+	}
+	for _, test := range tests {
+		move, err := game.ParseMove(test.move)
+		if err != nil {
+			t.Fatalf("Bad move %s: %v", test.move, err)
+		}
+		row, col := ToRowCol(*move)
+		if row != test.row || col != test.col {
+			t.Errorf("%s: expected row/col %d %d, got %d %d", test.move, test.row, test.col, row, col)
+		}
+		if got := ToIndex(*move); got != test.index {
+			t.Errorf("%s: expected index %d, got %d", test.move, test.index, got)
+		}
+		if got := ToBoardCell(*move); got != test.boardCell {
+			t.Errorf("%s: expected boardcell %d, got %d", test.move, test.boardCell, got)
+		}
+		if back, _ := FromRowCol(row, col); back != *move {
+			t.Errorf("%s: FromRowCol returned %s", test.move, back.ToString())
+		}
+		if back, _ := FromIndex(test.index); back != *move {
+			t.Errorf("%s: FromIndex returned %s", test.move, back.ToString())
+		}
+		if back, _ := FromBoardCell(test.boardCell); back != *move {
+			t.Errorf("%s: FromBoardCell returned %s", test.move, back.ToString())
+		}
+	}
+}
+
+func TestPlainFormatsRoundTrip(t *testing.T) {
+	moves, err := ParseMoves("4,4\n3 3\n1 1", FormatRowCol)
+	if err != nil {
+		t.Fatalf("ParseMoves failed: %v", err)
+	}
+	g, err := NewGameFromMoves("ext", "alice", "bob", moves)
+	if err != nil {
+		t.Fatalf("NewGameFromMoves failed: %v", err)
+	}
+	if g.GetMovesString() != "E5 E1 A5" {
+		t.Errorf("Expected E5 E1 A5, got %s", g.GetMovesString())
+	}
+
+	for _, format := range []Format{FormatRowCol, FormatIndex, FormatBoardCell} {
+		text, err := FormatMoves(g, format)
+		if err != nil {
+			t.Fatalf("%s: FormatMoves failed: %v", format, err)
+		}
+		back, err := ParseMoves(text, format)
+		if err != nil {
+			t.Fatalf("%s: ParseMoves failed: %v", format, err)
+		}
+		if len(back) != len(moves) {
+			t.Fatalf("%s: expected %d moves, got %d", format, len(moves), len(back))
+		}
+		for i := range back {
+			if back[i] != moves[i] {
+				t.Errorf("%s: move %d changed from %s to %s", format, i+1, moves[i].ToString(), back[i].ToString())
+			}
+		}
+	}
+
+	if _, err := NewGameFromMoves("bad", "a", "b", []game.Move{{BoardIndex: 4, Position: 4}, {BoardIndex: 0, Position: 0}}); err == nil {
+		t.Errorf("Expected illegal imported move to be rejected")
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	g := NewUGNGame("J", "alice", "bob")
+	g.AddMove(UGNMove{BoardIndex: 4, Position: 4, Comment: "center"})
+	g.Metadata.Extra.Set("Event", "club night")
+
+	data, err := json.Marshal(g.ToJSON())
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	games, err := DecodeJSONGames(data)
+	if err != nil {
+		t.Fatalf("DecodeJSONGames failed: %v", err)
+	}
+	back := games[0]
+	if back.Metadata.GameID != "J" || back.GetMovesString() != "E5" || back.Moves[0].Comment != "center" {
+		t.Errorf("Unexpected game after JSON round trip: %+v", back)
+	}
+	if v, _ := back.Metadata.Tag("Event"); v != "club night" {
+		t.Errorf("Expected Event tag to survive, got %q", v)
+	}
+}
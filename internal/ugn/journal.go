@@ -0,0 +1,181 @@
+package ugn
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+)
+
+const (
+	journalSuffix    = ".journal"
+	badJournalSuffix = ".bad"
+)
+
+// A journal is a UGN game without a result line: the headers (with
+// [Result "*"]) followed by one move per line, appended as moves are made.
+
+func (gl *GameLogger) rewriteJournal() error {
+	gl.closeJournal()
+
+	var sb strings.Builder
+	header := *gl.ugnGame
+	header.Metadata.Result = "*"
+	header.writeHeaders(&sb)
+	sb.WriteString("\n")
+	for _, move := range gl.ugnGame.Moves {
+		sb.WriteString(journalLine(move))
+	}
+
+	if err := replaceFile(gl.journalPath, []byte(sb.String())); err != nil {
+		return fmt.Errorf("failed to write journal: %v", err)
+	}
+	file, err := os.OpenFile(gl.journalPath, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %v", err)
+	}
+	gl.journal = file
+	return nil
+}
+
+func (gl *GameLogger) appendJournal(move UGNMove) error {
+	if gl.journal == nil {
+		return fmt.Errorf("journal is not open")
+	}
+	if _, err := gl.journal.WriteString(journalLine(move)); err != nil {
+		return fmt.Errorf("failed to append to journal: %v", err)
+	}
+	if err := gl.journal.Sync(); err != nil {
+		return fmt.Errorf("failed to sync journal: %v", err)
+	}
+	return nil
+}
+
+func (gl *GameLogger) closeJournal() {
+	if gl.journal != nil {
+		gl.journal.Close()
+		gl.journal = nil
+	}
+}
+
+func journalLine(move UGNMove) string {
+	if comment := moveComment(move); comment != "" {
+		return move.ToString() + " {" + comment + "}\n"
+	}
+	return move.ToString() + "\n"
+}
+
+// RecoverJournals finalizes journals left behind by games that never ended,
+// e.g. because the server stopped. A game the board shows as decided gets
+// that result; anything else is saved with result "*". It returns the paths
+// of the UGN files written.
+//
+// A journal that cannot be recovered is renamed to <name>.journal.bad so it
+// is not retried on every start, and recovery carries on with the rest; the
+// returned error lists every journal set aside.
+func RecoverJournals(gamesDir string) ([]string, error) {
+	journals, err := filepath.Glob(filepath.Join(gamesDir, "*"+journalSuffix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list journals: %v", err)
+	}
+	var recovered []string
+	var errs []error
+	for _, journal := range journals {
+		path, err := recoverJournal(journal)
+		if err != nil {
+			if renameErr := os.Rename(journal, journal+badJournalSuffix); renameErr != nil {
+				err = fmt.Errorf("%v (and could not set it aside: %v)", err, renameErr)
+			}
+			errs = append(errs, fmt.Errorf("%s: %v", journal, err))
+			continue
+		}
+		recovered = append(recovered, path)
+	}
+	return recovered, errors.Join(errs...)
+}
+
+func recoverJournal(journal string) (string, error) {
+	data, err := os.ReadFile(journal)
+	if err != nil {
+		return "", fmt.Errorf("failed to read journal: %v", err)
+	}
+	// A crash mid-write can leave a partial last line; drop it.
+	if i := bytes.LastIndexByte(data, '\n'); i >= 0 && i < len(data)-1 {
+		data = data[:i+1]
+	}
+
+	g, err := NewReader(bytes.NewReader(data)).Read()
+	if err == io.EOF {
+		return "", fmt.Errorf("journal is empty")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	board, replayErr := g.Replay(nil)
+	switch {
+	case replayErr == nil && board.State == game.XWins:
+		g.SetResult("X")
+	case replayErr == nil && board.State == game.OWins:
+		g.SetResult("O")
+	case replayErr == nil && board.State == game.Draw:
+		g.SetResult("Draw")
+	default:
+		g.SetResult("*")
+		g.SetComment("Game unfinished: recovered from journal")
+	}
+	g.NameOpening()
+
+	path := strings.TrimSuffix(journal, journalSuffix)
+	if err := writeFileAtomic(path, g); err != nil {
+		return "", err
+	}
+	if err := os.Remove(journal); err != nil {
+		return "", fmt.Errorf("failed to remove journal: %v", err)
+	}
+	return path, nil
+}
+
+func writeFileAtomic(path string, g *UGNGame) error {
+	var sb strings.Builder
+	g.writeTo(&sb)
+	return replaceFile(path, []byte(sb.String()))
+}
+
+// replaceFile writes data to a synced temporary file, renames it over path
+// and syncs the directory so the new entry survives a crash.
+func replaceFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := writeAndSync(tmp, data); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	if dir, err := os.Open(filepath.Dir(path)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+	return nil
+}
+
+func writeAndSync(path string, data []byte) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,145 @@
+package ugn
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+)
+
+func logMoves(t *testing.T, logger *GameLogger, moves ...string) {
+	t.Helper()
+	board := game.NewUltimateBoard()
+	for _, s := range moves {
+		move, err := game.ParseMove(s)
+		if err != nil {
+			t.Fatalf("Bad move %s: %v", s, err)
+		}
+		beforeGameState := board.State
+		beforeSmallState := board.Boards[move.BoardIndex].State
+		if err := board.MakeMove(move.BoardIndex, move.Position); err != nil {
+			t.Fatalf("Illegal move %s: %v", s, err)
+		}
+		if err := logger.LogMove(move, board, beforeGameState, beforeSmallState, game.MoveTiming{}); err != nil {
+			t.Fatalf("LogMove failed: %v", err)
+		}
+	}
+}
+
+func TestJournalWrittenAsMovesHappen(t *testing.T) {
+	dir := t.TempDir()
+	logger := NewGameLogger(dir)
+	if err := logger.StartGame("J1", "alice", "bob"); err != nil {
+		t.Fatalf("StartGame failed: %v", err)
+	}
+	logMoves(t, logger, "E5", "E1")
+
+	journal := filepath.Join(dir, logger.GetCurrentGame().GenerateFilename()+journalSuffix)
+	file, err := os.Open(journal)
+	if err != nil {
+		t.Fatalf("Expected journal file: %v", err)
+	}
+	defer file.Close()
+	g, err := NewReader(file).Read()
+	if err != nil {
+		t.Fatalf("Journal is not readable: %v", err)
+	}
+	if g.Metadata.Result != "*" || g.GetMovesString() != "E5 E1" {
+		t.Errorf("Unexpected journal contents: result %q moves %q", g.Metadata.Result, g.GetMovesString())
+	}
+
+	if err := logger.EndGameWithComment("X", "X wins by resignation"); err != nil {
+		t.Fatalf("EndGame failed: %v", err)
+	}
+	if _, err := os.Stat(journal); !os.IsNotExist(err) {
+		t.Errorf("Expected journal to be removed after the game ended")
+	}
+	final, err := ParseUGNFile(filepath.Join(dir, g.GenerateFilename()))
+	if err != nil {
+		t.Fatalf("Expected final UGN file: %v", err)
+	}
+	if final.Metadata.Result != "X" || final.GetMovesString() != "E5 E1" {
+		t.Errorf("Unexpected final game: %+v", final)
+	}
+	if name, _ := final.Metadata.Tag("Opening"); name != "Centre Opening, Corner Reply" {
+		t.Errorf("Expected opening to be named, got %q", name)
+	}
+}
+
+func TestUndoMoveRewritesJournal(t *testing.T) {
+	dir := t.TempDir()
+	logger := NewGameLogger(dir)
+	if err := logger.StartGame("J3", "alice", "bob"); err != nil {
+		t.Fatalf("StartGame failed: %v", err)
+	}
+	logMoves(t, logger, "E5", "E1", "A5")
+	if err := logger.UndoMove(); err != nil {
+		t.Fatalf("UndoMove failed: %v", err)
+	}
+	if moves := logger.GetUGNMovesString(); moves != "E5 E1" {
+		t.Errorf("Expected E5 E1 after undo, got %q", moves)
+	}
+
+	// The next move is appended to the rewritten journal.
+	board := game.NewUltimateBoard()
+	board.MakeMove(4, 4)
+	board.MakeMove(4, 0)
+	beforeGameState, beforeSmallState := board.State, board.Boards[0].State
+	board.MakeMove(0, 0)
+	logger.LogMove(&game.Move{BoardIndex: 0, Position: 0}, board, beforeGameState, beforeSmallState, game.MoveTiming{})
+
+	journal := filepath.Join(dir, logger.GetCurrentGame().GenerateFilename()+journalSuffix)
+	file, err := os.Open(journal)
+	if err != nil {
+		t.Fatalf("Expected journal file: %v", err)
+	}
+	defer file.Close()
+	g, err := NewReader(file).Read()
+	if err != nil {
+		t.Fatalf("Journal is not readable: %v", err)
+	}
+	if g.GetMovesString() != "E5 E1 A1" {
+		t.Errorf("Unexpected journal moves %q", g.GetMovesString())
+	}
+}
+
+func TestRecoverJournals(t *testing.T) {
+	dir := t.TempDir()
+	logger := NewGameLogger(dir)
+	if err := logger.StartGame("J2", "alice", "bob"); err != nil {
+		t.Fatalf("StartGame failed: %v", err)
+	}
+	logMoves(t, logger, "E5", "E1", "A5")
+	filename := logger.GetCurrentGame().GenerateFilename()
+
+	// Simulate a crash in the middle of writing the next move.
+	logger.journal.WriteString("E")
+	logger.closeJournal()
+
+	// An unreadable journal is set aside without stopping the others.
+	bad := filepath.Join(dir, "20260101_000000_BAD"+journalSuffix)
+	os.WriteFile(bad, []byte("[GameID \"BAD\"]\n\nZ9 Q4\n"), 0644)
+
+	recovered, err := RecoverJournals(dir)
+	if err == nil {
+		t.Error("Expected an error naming the bad journal")
+	}
+	if _, statErr := os.Stat(bad + badJournalSuffix); statErr != nil {
+		t.Errorf("Expected the bad journal to be moved aside: %v", statErr)
+	}
+	if len(recovered) != 1 || filepath.Base(recovered[0]) != filename {
+		t.Fatalf("Expected %s to be recovered, got %v", filename, recovered)
+	}
+
+	g, err := ParseUGNFile(recovered[0])
+	if err != nil {
+		t.Fatalf("Recovered file is not readable: %v", err)
+	}
+	if g.Metadata.Result != "*" || g.GetMovesString() != "E5 E1 A5" {
+		t.Errorf("Unexpected recovered game: result %q moves %q", g.Metadata.Result, g.GetMovesString())
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(dir, "*"+journalSuffix)); len(leftovers) != 0 {
+		t.Errorf("Expected journals to be removed, found %v", leftovers)
+	}
+}
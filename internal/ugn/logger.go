@@ -8,10 +8,16 @@ import (
 	"github.com/eshahhh/ultimatetictactoe/internal/game"
 )
 
+// GameLogger records a game as it is played. Moves are appended to a journal
+// file (<final name>.journal) and synced as they happen; when the game ends
+// the finished UGN file is written under a temporary name and renamed into
+// place, and the journal is removed.
 type GameLogger struct {
 	ugnGame     *UGNGame
 	gamesDir    string
 	gameStarted bool
+	journal     *os.File
+	journalPath string
 }
 
 func NewGameLogger(gamesDir string) *GameLogger {
@@ -25,18 +31,24 @@ func (gl *GameLogger) StartGame(gameID, playerX, playerO string) error {
 		return fmt.Errorf("failed to create games directory: %v", err)
 	}
 	gl.ugnGame = NewUGNGame(gameID, playerX, playerO)
+	gl.journalPath = filepath.Join(gl.gamesDir, gl.ugnGame.GenerateFilename()+journalSuffix)
+	if err := gl.rewriteJournal(); err != nil {
+		return err
+	}
 	gl.gameStarted = true
 	return nil
 }
 
-func (gl *GameLogger) LogMove(move *game.Move, board *game.UltimateBoard, beforeGameState game.BoardState, beforeSmallState game.BoardState) error {
+func (gl *GameLogger) LogMove(move *game.Move, board *game.UltimateBoard, beforeGameState game.BoardState, beforeSmallState game.BoardState, timing game.MoveTiming) error {
 	if !gl.gameStarted {
 		return fmt.Errorf("game logging not started")
 	}
 	ugnMove := GenerateUGNMove(move, board, beforeGameState, beforeSmallState)
+	ugnMove.Elapsed = timing.Elapsed
+	ugnMove.Clock = timing.Remaining
 	gl.ugnGame.AddMove(*ugnMove)
 	fmt.Printf("[UGN Logger] Move logged: %s, Total moves: %d\n", ugnMove.ToString(), len(gl.ugnGame.Moves))
-	return nil
+	return gl.appendJournal(*ugnMove)
 }
 
 func (gl *GameLogger) EndGame(result string) error {
@@ -51,16 +63,43 @@ func (gl *GameLogger) EndGameWithComment(result, comment string) error {
 	if comment != "" {
 		gl.ugnGame.SetComment(comment)
 	}
+	gl.ugnGame.NameOpening()
 	filename := gl.ugnGame.GenerateFilename()
-	filepath := filepath.Join(gl.gamesDir, filename)
-	err := gl.ugnGame.WriteUGNFile(filepath)
-	if err != nil {
+	if err := writeFileAtomic(filepath.Join(gl.gamesDir, filename), gl.ugnGame); err != nil {
 		return fmt.Errorf("failed to save UGN file: %v", err)
 	}
+	gl.closeJournal()
+	if err := os.Remove(gl.journalPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove journal: %v", err)
+	}
 	gl.gameStarted = false
 	return nil
 }
 
+// UndoMove drops the last logged move, as after a takeback, and rewrites
+// the journal without it.
+func (gl *GameLogger) UndoMove() error {
+	if !gl.gameStarted {
+		return fmt.Errorf("game logging not started")
+	}
+	if len(gl.ugnGame.Moves) == 0 {
+		return fmt.Errorf("no moves to undo")
+	}
+	gl.ugnGame.Moves = gl.ugnGame.Moves[:len(gl.ugnGame.Moves)-1]
+	return gl.rewriteJournal()
+}
+
+func (gl *GameLogger) SetTag(name, value string) error {
+	if gl.ugnGame == nil {
+		return fmt.Errorf("game logging not started")
+	}
+	gl.ugnGame.Metadata.SetTag(name, value)
+	if gl.gameStarted {
+		return gl.rewriteJournal()
+	}
+	return nil
+}
+
 func (gl *GameLogger) GetCurrentGame() *UGNGame {
 	if gl.ugnGame != nil {
 		fmt.Printf("[UGN Logger] GetCurrentGame called, moves count: %d\n", len(gl.ugnGame.Moves))
@@ -80,3 +119,11 @@ func (gl *GameLogger) GetUGNMovesString() string {
 	}
 	return gl.ugnGame.GetMovesString()
 }
+
+// RecordPath is where the finished game is written.
+func (gl *GameLogger) RecordPath() string {
+	if gl.ugnGame == nil {
+		return ""
+	}
+	return filepath.Join(gl.gamesDir, gl.ugnGame.GenerateFilename())
+}
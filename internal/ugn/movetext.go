@@ -0,0 +1,228 @@
+package ugn
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Variation is an alternative to the move at index Ply of its parent line,
+// played from the position before that move.
+type Variation struct {
+	Ply        int
+	Intro      string // comment before the first move of the variation
+	Moves      []UGNMove
+	Variations []Variation
+}
+
+type lineState struct {
+	ply        int
+	intro      string
+	moves      []UGNMove
+	variations []Variation
+}
+
+// movetextParser turns movetext into the main line and its variations. It is
+// fed one source line at a time because comments may span lines.
+type movetextParser struct {
+	stack       []*lineState
+	inComment   bool
+	escaped     bool
+	comment     strings.Builder
+	commentPos  Position
+	word        strings.Builder
+	wordPos     Position
+	ended       bool
+	resultToken string
+	resultPos   Position
+}
+
+func newMovetextParser() *movetextParser {
+	return &movetextParser{
+		stack: []*lineState{{}},
+	}
+}
+
+func (p *movetextParser) current() *lineState {
+	return p.stack[len(p.stack)-1]
+}
+
+func (p *movetextParser) depth() int {
+	return len(p.stack) - 1
+}
+
+func (p *movetextParser) feed(line sourceLine) error {
+	for i := 0; i < len(line.text); i++ {
+		ch := line.text[i]
+		pos := Position{Line: line.num, Column: line.indent + i + 1}
+
+		if p.inComment {
+			switch {
+			case p.escaped:
+				p.comment.WriteByte(ch)
+				p.escaped = false
+			case ch == '\\':
+				p.escaped = true
+			case ch == '}':
+				p.inComment = false
+				p.attachComment(p.comment.String())
+			default:
+				p.comment.WriteByte(ch)
+			}
+			continue
+		}
+
+		switch ch {
+		case ' ', '\t':
+			if err := p.flushWord(); err != nil || p.ended {
+				return err
+			}
+		case '{':
+			if err := p.flushWord(); err != nil || p.ended {
+				return err
+			}
+			p.inComment = true
+			p.comment.Reset()
+			p.commentPos = pos
+		case '(':
+			if err := p.flushWord(); err != nil || p.ended {
+				return err
+			}
+			cur := p.current()
+			if len(cur.moves) == 0 {
+				return fmt.Errorf("%s: variation must follow the move it replaces", pos)
+			}
+			p.stack = append(p.stack, &lineState{ply: len(cur.moves) - 1})
+		case ')':
+			if err := p.flushWord(); err != nil || p.ended {
+				return err
+			}
+			if p.depth() == 0 {
+				return fmt.Errorf("%s: unmatched ')'", pos)
+			}
+			closed := p.current()
+			p.stack = p.stack[:len(p.stack)-1]
+			parent := p.current()
+			parent.variations = append(parent.variations, Variation{
+				Ply:        closed.ply,
+				Intro:      closed.intro,
+				Moves:      closed.moves,
+				Variations: closed.variations,
+			})
+		default:
+			if p.word.Len() == 0 {
+				p.wordPos = pos
+			}
+			p.word.WriteByte(ch)
+		}
+	}
+
+	if p.inComment {
+		p.comment.WriteByte(' ')
+		return nil
+	}
+	return p.flushWord()
+}
+
+func (p *movetextParser) flushWord() error {
+	if p.word.Len() == 0 {
+		return nil
+	}
+	text := p.word.String()
+	p.word.Reset()
+
+	if isResultToken(text) {
+		if p.depth() > 0 {
+			return fmt.Errorf("%s: result %s inside a variation", p.wordPos, text)
+		}
+		p.resultToken = text
+		p.resultPos = p.wordPos
+		p.ended = true
+		return nil
+	}
+
+	move, err := ParseMove(text)
+	if err != nil {
+		return fmt.Errorf("%s: failed to parse move '%s': %v", p.wordPos, text, err)
+	}
+	move.Pos = p.wordPos
+	cur := p.current()
+	cur.moves = append(cur.moves, *move)
+	return nil
+}
+
+func (p *movetextParser) attachComment(raw string) {
+	text := strings.Join(strings.Fields(raw), " ")
+	if text == "" {
+		return
+	}
+	cur := p.current()
+	if len(cur.moves) == 0 {
+		cur.intro = joinComment(cur.intro, text)
+		return
+	}
+	last := &cur.moves[len(cur.moves)-1]
+	text = extractTiming(last, text)
+	if text != "" {
+		last.Comment = joinComment(last.Comment, text)
+	}
+}
+
+func (p *movetextParser) finish(g *UGNGame) error {
+	if p.inComment {
+		return fmt.Errorf("%s: unterminated comment", p.commentPos)
+	}
+	if p.depth() > 0 {
+		return fmt.Errorf("unterminated variation")
+	}
+	main := p.stack[0]
+	g.Intro = main.intro
+	g.Moves = append(g.Moves, main.moves...)
+	g.Variations = main.variations
+	g.ResultToken = p.resultToken
+	g.ResultPos = p.resultPos
+	return nil
+}
+
+func joinComment(existing, text string) string {
+	if existing == "" {
+		return text
+	}
+	return existing + " " + text
+}
+
+// writeMovetext writes a line of moves with comments and variations. The main
+// line keeps one move pair per row; variations are written inline.
+func writeMovetext(sb *strings.Builder, intro string, moves []UGNMove, variations []Variation, mainLine bool) {
+	if intro != "" {
+		sb.WriteString("{" + escapeComment(intro) + "}")
+		if mainLine {
+			sb.WriteString("\n")
+		} else if len(moves) > 0 {
+			sb.WriteString(" ")
+		}
+	}
+	for i, move := range moves {
+		if mainLine && i > 0 && i%2 == 0 {
+			sb.WriteString("\n")
+		} else if i > 0 {
+			sb.WriteString(" ")
+		}
+		sb.WriteString(move.ToString())
+		if comment := moveComment(move); comment != "" {
+			sb.WriteString(" {" + comment + "}")
+		}
+		for _, v := range variations {
+			if v.Ply != i {
+				continue
+			}
+			sb.WriteString(" (")
+			writeMovetext(sb, v.Intro, v.Moves, v.Variations, false)
+			sb.WriteString(")")
+		}
+	}
+}
+
+func escapeComment(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\\`)
+	return strings.ReplaceAll(text, "}", `\}`)
+}
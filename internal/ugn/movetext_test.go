@@ -0,0 +1,95 @@
+package ugn
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const annotatedGame = `[GameID "study"]
+[Date "2025-07-01"]
+[Time "10:00:00"]
+[PlayerX "alice"]
+[PlayerO "bob"]
+[Result "*"]
+
+{Study session: the E5 opening}
+E5 {the usual start} E1 (E9 {sends X to the corner} I5 (I1 A9) E2)
+A5 E4 {O \} braces} (E6)
+*
+`
+
+func TestReaderCommentsAndVariations(t *testing.T) {
+	g, err := NewReader(strings.NewReader(annotatedGame)).Read()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if g.Intro != "Study session: the E5 opening" {
+		t.Errorf("Unexpected intro %q", g.Intro)
+	}
+	if g.GetMovesString() != "E5 E1 A5 E4" {
+		t.Errorf("Unexpected main line %q", g.GetMovesString())
+	}
+	if g.Moves[0].Comment != "the usual start" || g.Moves[3].Comment != "O } braces" {
+		t.Errorf("Comments not attached: %q %q", g.Moves[0].Comment, g.Moves[3].Comment)
+	}
+	if len(g.Variations) != 2 {
+		t.Fatalf("Expected 2 variations, got %d", len(g.Variations))
+	}
+
+	first := g.Variations[0]
+	if first.Ply != 1 || len(first.Moves) != 3 || first.Moves[0].Comment != "sends X to the corner" {
+		t.Errorf("Unexpected first variation %+v", first)
+	}
+	if len(first.Variations) != 1 || first.Variations[0].Ply != 1 || first.Variations[0].Moves[1].ToString() != "A9" {
+		t.Errorf("Unexpected nested variation %+v", first.Variations)
+	}
+	if g.Variations[1].Ply != 3 {
+		t.Errorf("Expected second variation to replace move 4, got ply %d", g.Variations[1].Ply)
+	}
+
+	if errs := Validate(g); len(errs) != 0 {
+		t.Errorf("Expected annotated game to validate, got %v", errs)
+	}
+}
+
+func TestCommentsAndVariationsRoundTrip(t *testing.T) {
+	g, err := NewReader(strings.NewReader(annotatedGame)).Read()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := NewWriter(&buf).Write(g); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if buf.String() != annotatedGame {
+		t.Errorf("Round trip changed the game:\n%s", buf.String())
+	}
+}
+
+func TestReaderRejectsBadMovetext(t *testing.T) {
+	inputs := []string{
+		"(E5)\n*\n",
+		"E5 E1)\n*\n",
+		"E5 (E1\n*\n",
+		"E5 {unterminated\n",
+	}
+	for _, input := range inputs {
+		if _, err := NewReader(strings.NewReader(input)).Read(); err == nil {
+			t.Errorf("Expected error for %q", input)
+		}
+	}
+}
+
+func TestValidateChecksVariations(t *testing.T) {
+	g, err := NewReader(strings.NewReader("[Result \"*\"]\n\nE5 E1 (A1)\n*\n")).Read()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	errs := Validate(g)
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "must play on board E") || errs[0].Pos.String() != "3:8" {
+		t.Errorf("Expected illegal variation move at 3:8, got %v", errs)
+	}
+}
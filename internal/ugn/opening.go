@@ -0,0 +1,23 @@
+package ugn
+
+import (
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+	"github.com/eshahhh/ultimatetictactoe/internal/opening"
+)
+
+// NameOpening sets the Opening tag from the built-in catalogue unless the
+// game already has one. It reports whether the tag is set afterwards.
+func (g *UGNGame) NameOpening() bool {
+	if name, ok := g.Metadata.Tag("Opening"); ok && name != "" {
+		return true
+	}
+	moves := make([]game.Move, len(g.Moves))
+	for i, m := range g.Moves {
+		moves[i] = game.Move{BoardIndex: m.BoardIndex, Position: m.Position}
+	}
+	o, ok := opening.Default().Classify(moves)
+	if ok {
+		g.Metadata.SetTag("Opening", o.Name)
+	}
+	return ok
+}
@@ -1,10 +1,12 @@
 package ugn
 
 import (
-	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -19,21 +21,40 @@ type UGNMove struct {
 	SmallDraw  bool
 	GameDraw   bool
 	GameWin    bool
+	Comment    string        // {comment} following the move
+	Elapsed    time.Duration // time spent on the move ([%emt]); zero if not recorded
+	Clock      time.Duration // mover's remaining clock ([%clk]); zero if not recorded
+	Pos        Position      // where the move was read from; zero if not parsed from text
+}
+
+type Position struct {
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
 }
 
 type GameMetadata struct {
-	GameID  string
-	Date    string
-	Time    string
-	PlayerX string
-	PlayerO string
-	Result  string
-	Comment string // comment for the game result (e.g., "X wins by resignation")
+	GameID      string
+	Date        string
+	Time        string
+	PlayerX     string
+	PlayerO     string
+	Result      string
+	Comment     string // comment for the game result (e.g., "X wins by resignation")
+	TimeControl string // "-" for untimed games
+	Extra       Tags   // any other tags, in file order
 }
 
 type UGNGame struct {
-	Metadata GameMetadata
-	Moves    []UGNMove
+	Metadata    GameMetadata
+	Intro       string      // comment before the first move
+	Moves       []UGNMove   // main line
+	Variations  []Variation // alternatives to main-line moves
+	ResultToken string      // result line as read ("1-0", "*", ...); empty if missing
+	ResultPos   Position    // position of ResultToken
 }
 
 func ParseMove(moveStr string) (*UGNMove, error) {
@@ -112,61 +133,14 @@ func ParseUGNFile(filename string) (*UGNGame, error) {
 		return nil, fmt.Errorf("failed to open UGN file: %v", err)
 	}
 	defer file.Close()
-	game := &UGNGame{}
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			break
-		}
-		re := regexp.MustCompile(`^\[(\w+)\s+"([^"]+)"\]$`)
-		matches := re.FindStringSubmatch(line)
-		if len(matches) == 3 {
-			key := matches[1]
-			value := matches[2]
-			switch key {
-			case "GameID":
-				game.Metadata.GameID = value
-			case "Date":
-				game.Metadata.Date = value
-			case "Time":
-				game.Metadata.Time = value
-			case "PlayerX":
-				game.Metadata.PlayerX = value
-			case "PlayerO":
-				game.Metadata.PlayerO = value
-			case "Result":
-				game.Metadata.Result = value
-			case "Comment":
-				game.Metadata.Comment = value
-			}
-		}
+	g, err := NewReader(file).Read()
+	if err == io.EOF {
+		return &UGNGame{}, nil
 	}
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
-		}
-
-		// Check if this is a result line (1-0, 0-1, 1/2-1/2, or *)
-		if line == "1-0" || line == "0-1" || line == "1/2-1/2" || line == "*" {
-			// We can skip it as the result is already in metadata
-			continue
-		}
-
-		moveStrings := strings.Fields(line)
-		for _, moveStr := range moveStrings {
-			move, err := ParseMove(moveStr)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse move '%s': %v", moveStr, err)
-			}
-			game.Moves = append(game.Moves, *move)
-		}
-	}
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading UGN file: %v", err)
+	if err != nil {
+		return nil, err
 	}
-	return game, nil
+	return g, nil
 }
 
 func (g *UGNGame) WriteUGNFile(filename string) error {
@@ -175,40 +149,7 @@ func (g *UGNGame) WriteUGNFile(filename string) error {
 		return fmt.Errorf("failed to create UGN file: %v", err)
 	}
 	defer file.Close()
-	fmt.Fprintf(file, "[GameID \"%s\"]\n", g.Metadata.GameID)
-	fmt.Fprintf(file, "[Date \"%s\"]\n", g.Metadata.Date)
-	fmt.Fprintf(file, "[Time \"%s\"]\n", g.Metadata.Time)
-	fmt.Fprintf(file, "[PlayerX \"%s\"]\n", g.Metadata.PlayerX)
-	fmt.Fprintf(file, "[PlayerO \"%s\"]\n", g.Metadata.PlayerO)
-	fmt.Fprintf(file, "[Result \"%s\"]\n", g.Metadata.Result)
-	if g.Metadata.Comment != "" {
-		fmt.Fprintf(file, "[Comment \"%s\"]\n", g.Metadata.Comment)
-	}
-	fmt.Fprintf(file, "\n")
-	for i, move := range g.Moves {
-		if i > 0 && i%2 == 0 {
-			fmt.Fprintf(file, "\n")
-		} else if i > 0 {
-			fmt.Fprintf(file, " ")
-		}
-		fmt.Fprintf(file, "%s", move.ToString())
-	}
-	fmt.Fprintf(file, "\n")
-
-	var resultLine string
-	switch g.Metadata.Result {
-	case "X":
-		resultLine = "1-0"
-	case "O":
-		resultLine = "0-1"
-	case "Draw":
-		resultLine = "1/2-1/2"
-	default:
-		resultLine = "*" // In progress or unknown result
-	}
-	fmt.Fprintf(file, "%s\n", resultLine)
-
-	return nil
+	return NewWriter(file).Write(g)
 }
 
 func (g *UGNGame) GenerateFilename() string {
@@ -221,12 +162,13 @@ func NewUGNGame(gameID, playerX, playerO string) *UGNGame {
 	now := time.Now()
 	return &UGNGame{
 		Metadata: GameMetadata{
-			GameID:  gameID,
-			Date:    now.Format("2006-01-02"),
-			Time:    now.Format("15:04:05"),
-			PlayerX: playerX,
-			PlayerO: playerO,
-			Result:  "In Progress",
+			GameID:      gameID,
+			Date:        now.Format("2006-01-02"),
+			Time:        now.Format("15:04:05"),
+			PlayerX:     playerX,
+			PlayerO:     playerO,
+			Result:      "In Progress",
+			TimeControl: "-",
 		},
 		Moves: make([]UGNMove, 0),
 	}
@@ -251,3 +193,44 @@ func (g *UGNGame) GetMovesString() string {
 	}
 	return strings.Join(moves, " ")
 }
+
+// ParseUGNDir parses every .ugn file in dir. Files that fail to parse are
+// skipped; the games that did parse are returned together with an error
+// naming the skipped files.
+func ParseUGNDir(dir string) ([]*UGNGame, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.ugn"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list UGN files: %v", err)
+	}
+	sort.Strings(files)
+	games := make([]*UGNGame, 0, len(files))
+	var errs []string
+	for _, file := range files {
+		g, err := ParseUGNFile(file)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", file, err))
+			continue
+		}
+		games = append(games, g)
+	}
+	if len(errs) > 0 {
+		return games, fmt.Errorf("skipped %d file(s): %s", len(errs), strings.Join(errs, "; "))
+	}
+	return games, nil
+}
+
+// Replay plays the moves on a fresh board, calling visit before each move.
+func (g *UGNGame) Replay(visit func(ply int, board *game.UltimateBoard, move UGNMove) error) (*game.UltimateBoard, error) {
+	board := game.NewUltimateBoard()
+	for i, move := range g.Moves {
+		if visit != nil {
+			if err := visit(i, board, move); err != nil {
+				return board, err
+			}
+		}
+		if err := board.MakeMove(move.BoardIndex, move.Position); err != nil {
+			return board, fmt.Errorf("move %d (%s): %v", i+1, move.ToString(), err)
+		}
+	}
+	return board, nil
+}
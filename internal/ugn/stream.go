@@ -0,0 +1,200 @@
+package ugn
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var tagPattern = regexp.MustCompile(`^\[(\w+)\s+"((?:[^"\\]|\\.)*)"\]$`)
+
+// Reader reads successive games from a stream of concatenated UGN games.
+// A game ends at its result line or where the next game's headers begin.
+type Reader struct {
+	scanner    *bufio.Scanner
+	lineNum    int
+	pending    sourceLine
+	hasPending bool
+}
+
+type sourceLine struct {
+	text   string
+	num    int
+	indent int
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		scanner: bufio.NewScanner(r),
+	}
+}
+
+func (r *Reader) nextLine() (sourceLine, bool) {
+	if r.hasPending {
+		r.hasPending = false
+		return r.pending, true
+	}
+	if !r.scanner.Scan() {
+		return sourceLine{}, false
+	}
+	r.lineNum++
+	raw := r.scanner.Text()
+	trimmed := strings.TrimLeft(raw, " \t")
+	return sourceLine{
+		text:   strings.TrimSpace(trimmed),
+		num:    r.lineNum,
+		indent: len(raw) - len(trimmed),
+	}, true
+}
+
+func (r *Reader) unreadLine(line sourceLine) {
+	r.pending = line
+	r.hasPending = true
+}
+
+// Read returns the next game, or io.EOF when the stream holds no more games.
+func (r *Reader) Read() (*UGNGame, error) {
+	g := &UGNGame{}
+	found := false
+
+	for {
+		line, ok := r.nextLine()
+		if !ok {
+			break
+		}
+		if line.text == "" {
+			if found {
+				break
+			}
+			continue
+		}
+		if !strings.HasPrefix(line.text, "[") {
+			r.unreadLine(line)
+			break
+		}
+		found = true
+		matches := tagPattern.FindStringSubmatch(line.text)
+		if len(matches) == 3 {
+			g.Metadata.SetTag(matches[1], unescapeTagValue(matches[2]))
+		}
+	}
+
+	movetext := newMovetextParser()
+	for {
+		line, ok := r.nextLine()
+		if !ok {
+			break
+		}
+		if !movetext.inComment {
+			if line.text == "" {
+				continue
+			}
+			if movetext.depth() == 0 && strings.HasPrefix(line.text, "[") {
+				r.unreadLine(line)
+				break
+			}
+		}
+		found = true
+		if err := movetext.feed(line); err != nil {
+			return nil, err
+		}
+		if movetext.ended {
+			break
+		}
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading UGN: %v", err)
+	}
+	if !found {
+		return nil, io.EOF
+	}
+	if err := movetext.finish(g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func (r *Reader) ReadAll() ([]*UGNGame, error) {
+	var games []*UGNGame
+	for {
+		g, err := r.Read()
+		if err == io.EOF {
+			return games, nil
+		}
+		if err != nil {
+			return games, err
+		}
+		games = append(games, g)
+	}
+}
+
+// Writer writes games to a stream, separating consecutive games with a blank line.
+type Writer struct {
+	w     io.Writer
+	count int
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+func (w *Writer) Write(g *UGNGame) error {
+	var sb strings.Builder
+	if w.count > 0 {
+		sb.WriteString("\n")
+	}
+	g.writeTo(&sb)
+	if _, err := io.WriteString(w.w, sb.String()); err != nil {
+		return fmt.Errorf("failed to write UGN game: %v", err)
+	}
+	w.count++
+	return nil
+}
+
+func (g *UGNGame) writeTo(sb *strings.Builder) {
+	g.writeHeaders(sb)
+	sb.WriteString("\n")
+	writeMovetext(sb, g.Intro, g.Moves, g.Variations, true)
+	sb.WriteString("\n")
+	sb.WriteString(ResultLine(g.Metadata.Result))
+	sb.WriteString("\n")
+}
+
+func (g *UGNGame) writeHeaders(sb *strings.Builder) {
+	writeTag(sb, "GameID", g.Metadata.GameID)
+	writeTag(sb, "Date", g.Metadata.Date)
+	writeTag(sb, "Time", g.Metadata.Time)
+	writeTag(sb, "PlayerX", g.Metadata.PlayerX)
+	writeTag(sb, "PlayerO", g.Metadata.PlayerO)
+	writeTag(sb, "Result", g.Metadata.Result)
+	if g.Metadata.Comment != "" {
+		writeTag(sb, "Comment", g.Metadata.Comment)
+	}
+	if g.Metadata.TimeControl != "" {
+		writeTag(sb, "TimeControl", g.Metadata.TimeControl)
+	}
+	for _, tag := range g.Metadata.Extra {
+		writeTag(sb, tag.Name, tag.Value)
+	}
+}
+
+// ResultLine maps a [Result] value to the line that terminates the movetext.
+func ResultLine(result string) string {
+	switch result {
+	case "X":
+		return "1-0"
+	case "O":
+		return "0-1"
+	case "Draw":
+		return "1/2-1/2"
+	default:
+		return "*" // In progress or unknown result
+	}
+}
+
+func isResultToken(token string) bool {
+	return token == "1-0" || token == "0-1" || token == "1/2-1/2" || token == "*"
+}
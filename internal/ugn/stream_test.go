@@ -0,0 +1,92 @@
+package ugn
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReaderMultipleGames(t *testing.T) {
+	input := `[GameID "one"]
+[PlayerX "alice"]
+[PlayerO "bob"]
+[Result "X"]
+
+E5 E1
+A5
+1-0
+[GameID "two"]
+[Result "Draw"]
+
+C3 C9
+1/2-1/2
+
+[GameID "three"]
+
+A1 A2
+`
+	games, err := NewReader(strings.NewReader(input)).ReadAll()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(games) != 3 {
+		t.Fatalf("Expected 3 games, got %d", len(games))
+	}
+
+	expected := []struct {
+		id    string
+		moves string
+	}{
+		{"one", "E5 E1 A5"},
+		{"two", "C3 C9"},
+		{"three", "A1 A2"},
+	}
+	for i, exp := range expected {
+		if games[i].Metadata.GameID != exp.id {
+			t.Errorf("Game %d: expected ID %s, got %s", i, exp.id, games[i].Metadata.GameID)
+		}
+		if games[i].GetMovesString() != exp.moves {
+			t.Errorf("Game %d: expected moves '%s', got '%s'", i, exp.moves, games[i].GetMovesString())
+		}
+	}
+	if games[0].Metadata.PlayerX != "alice" || games[1].Metadata.Result != "Draw" {
+		t.Errorf("Metadata not parsed: %+v %+v", games[0].Metadata, games[1].Metadata)
+	}
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	first := NewUGNGame("first", "alice", "bob")
+	first.AddMove(UGNMove{BoardIndex: 4, Position: 4})
+	first.AddMove(UGNMove{BoardIndex: 4, Position: 0})
+	first.SetResult("O")
+	first.SetComment("O wins by resignation")
+	second := NewUGNGame("second", "carol", "dave")
+	second.AddMove(UGNMove{BoardIndex: 0, Position: 0})
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, g := range []*UGNGame{first, second} {
+		if err := w.Write(g); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	r := NewReader(&buf)
+	for _, want := range []*UGNGame{first, second} {
+		got, err := r.Read()
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		if !reflect.DeepEqual(got.Metadata, want.Metadata) {
+			t.Errorf("Expected metadata %+v, got %+v", want.Metadata, got.Metadata)
+		}
+		if got.GetMovesString() != want.GetMovesString() {
+			t.Errorf("Expected moves '%s', got '%s'", want.GetMovesString(), got.GetMovesString())
+		}
+	}
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("Expected io.EOF after last game, got %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package ugn
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Tag struct {
+	Name  string
+	Value string
+}
+
+// Tags is an ordered list of header tags beyond the standard GameMetadata fields.
+type Tags []Tag
+
+func (t Tags) Get(name string) (string, bool) {
+	for _, tag := range t {
+		if tag.Name == name {
+			return tag.Value, true
+		}
+	}
+	return "", false
+}
+
+// Set replaces the value of an existing tag or appends a new one.
+func (t *Tags) Set(name, value string) {
+	for i, tag := range *t {
+		if tag.Name == name {
+			(*t)[i].Value = value
+			return
+		}
+	}
+	*t = append(*t, Tag{Name: name, Value: value})
+}
+
+func (t *Tags) Delete(name string) {
+	for i, tag := range *t {
+		if tag.Name == name {
+			*t = append((*t)[:i], (*t)[i+1:]...)
+			return
+		}
+	}
+}
+
+// Tag returns the value of a standard or extra tag.
+func (m *GameMetadata) Tag(name string) (string, bool) {
+	switch name {
+	case "GameID":
+		return m.GameID, true
+	case "Date":
+		return m.Date, true
+	case "Time":
+		return m.Time, true
+	case "PlayerX":
+		return m.PlayerX, true
+	case "PlayerO":
+		return m.PlayerO, true
+	case "Result":
+		return m.Result, true
+	case "Comment":
+		return m.Comment, m.Comment != ""
+	case "TimeControl":
+		return m.TimeControl, m.TimeControl != ""
+	}
+	return m.Extra.Get(name)
+}
+
+// SetTag sets a standard field by tag name, keeping any other tag in Extra.
+func (m *GameMetadata) SetTag(name, value string) {
+	switch name {
+	case "GameID":
+		m.GameID = value
+	case "Date":
+		m.Date = value
+	case "Time":
+		m.Time = value
+	case "PlayerX":
+		m.PlayerX = value
+	case "PlayerO":
+		m.PlayerO = value
+	case "Result":
+		m.Result = value
+	case "Comment":
+		m.Comment = value
+	case "TimeControl":
+		m.TimeControl = value
+	default:
+		m.Extra.Set(name, value)
+	}
+}
+
+func writeTag(sb *strings.Builder, name, value string) {
+	fmt.Fprintf(sb, "[%s \"%s\"]\n", name, escapeTagValue(value))
+}
+
+func escapeTagValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return strings.ReplaceAll(value, `"`, `\"`)
+}
+
+func unescapeTagValue(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) {
+			i++
+		}
+		sb.WriteByte(value[i])
+	}
+	return sb.String()
+}
@@ -0,0 +1,75 @@
+package ugn
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtraTagsRoundTrip(t *testing.T) {
+	input := `[GameID "T1"]
+[Date "2025-07-01"]
+[Time "12:00:00"]
+[PlayerX "Bob \"the\" Builder"]
+[PlayerO "C:\\Users\\carol"]
+[Result "X"]
+[Comment "X wins by resignation"]
+[Event "Office Championship"]
+[Round "3"]
+[Analyzer "uttt-engine \"v2\""]
+
+E5
+1-0
+`
+	g, err := NewReader(strings.NewReader(input)).Read()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if g.Metadata.PlayerX != `Bob "the" Builder` {
+		t.Errorf("Expected unescaped PlayerX, got %q", g.Metadata.PlayerX)
+	}
+	if g.Metadata.PlayerO != `C:\Users\carol` {
+		t.Errorf("Expected unescaped PlayerO, got %q", g.Metadata.PlayerO)
+	}
+	expected := Tags{
+		{Name: "Event", Value: "Office Championship"},
+		{Name: "Round", Value: "3"},
+		{Name: "Analyzer", Value: `uttt-engine "v2"`},
+	}
+	if !reflect.DeepEqual(g.Metadata.Extra, expected) {
+		t.Errorf("Expected extra tags %+v, got %+v", expected, g.Metadata.Extra)
+	}
+
+	var buf bytes.Buffer
+	if err := NewWriter(&buf).Write(g); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if buf.String() != input {
+		t.Errorf("Round trip changed the game:\n%s", buf.String())
+	}
+}
+
+func TestMetadataTagAccessors(t *testing.T) {
+	var m GameMetadata
+	m.SetTag("PlayerX", "alice")
+	m.SetTag("Event", "Friday blitz")
+	m.SetTag("Site", "office")
+	m.SetTag("Event", "Monday blitz")
+
+	if v, ok := m.Tag("PlayerX"); !ok || v != "alice" || m.PlayerX != "alice" {
+		t.Errorf("Expected PlayerX field to be set, got %q", m.PlayerX)
+	}
+	if v, ok := m.Tag("Event"); !ok || v != "Monday blitz" {
+		t.Errorf("Expected Event to be replaced, got %q", v)
+	}
+	if len(m.Extra) != 2 || m.Extra[0].Name != "Event" || m.Extra[1].Name != "Site" {
+		t.Errorf("Expected extra tags in insertion order, got %+v", m.Extra)
+	}
+
+	m.Extra.Delete("Event")
+	if _, ok := m.Tag("Event"); ok {
+		t.Errorf("Expected Event to be deleted")
+	}
+}
@@ -0,0 +1,83 @@
+package ugn
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Timing is embedded in move comments as [%emt H:MM:SS] for the time spent
+// on the move and [%clk H:MM:SS] for the clock left afterwards. Seconds may
+// carry a fraction, e.g. [%emt 0:00:02.5].
+var timingPattern = regexp.MustCompile(`\[%(clk|emt)\s+([0-9:.]+)\]`)
+
+func extractTiming(move *UGNMove, text string) string {
+	text = timingPattern.ReplaceAllStringFunc(text, func(cmd string) string {
+		matches := timingPattern.FindStringSubmatch(cmd)
+		d, err := parseClock(matches[2])
+		if err != nil {
+			return cmd
+		}
+		if matches[1] == "clk" {
+			move.Clock = d
+		} else {
+			move.Elapsed = d
+		}
+		return ""
+	})
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func moveComment(move UGNMove) string {
+	var parts []string
+	if move.Elapsed > 0 {
+		parts = append(parts, "[%emt "+formatClock(move.Elapsed)+"]")
+	}
+	if move.Clock > 0 {
+		parts = append(parts, "[%clk "+formatClock(move.Clock)+"]")
+	}
+	if move.Comment != "" {
+		parts = append(parts, escapeComment(move.Comment))
+	}
+	return strings.Join(parts, " ")
+}
+
+func parseClock(s string) (time.Duration, error) {
+	fields := strings.Split(s, ":")
+	if len(fields) > 3 {
+		return 0, fmt.Errorf("invalid clock value: %s", s)
+	}
+	var total time.Duration
+	for i, field := range fields {
+		last := i == len(fields)-1
+		if last {
+			seconds, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid clock value: %s", s)
+			}
+			total = total*60 + time.Duration(seconds*float64(time.Second))
+		} else {
+			n, err := strconv.Atoi(field)
+			if err != nil {
+				return 0, fmt.Errorf("invalid clock value: %s", s)
+			}
+			total = total*60 + time.Duration(n)*time.Second
+		}
+	}
+	return total, nil
+}
+
+// formatClock writes H:MM:SS, adding tenths of a second when they are non-zero.
+func formatClock(d time.Duration) string {
+	d = d.Round(100 * time.Millisecond)
+	hours := int(d / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+	seconds := int(d % time.Minute / time.Second)
+	tenths := int(d % time.Second / (100 * time.Millisecond))
+	if tenths != 0 {
+		return fmt.Sprintf("%d:%02d:%02d.%d", hours, minutes, seconds, tenths)
+	}
+	return fmt.Sprintf("%d:%02d:%02d", hours, minutes, seconds)
+}
@@ -0,0 +1,66 @@
+package ugn
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+)
+
+func TestTimingAnnotations(t *testing.T) {
+	input := `[GameID "clock"]
+[Result "*"]
+[TimeControl "300+2"]
+
+E5 {[%emt 0:00:04] [%clk 0:04:58]} E1 {[%emt 0:00:12.5] [%clk 0:04:49.5] slow}
+*
+`
+	g, err := NewReader(strings.NewReader(input)).Read()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if g.Metadata.TimeControl != "300+2" {
+		t.Errorf("Expected TimeControl 300+2, got %q", g.Metadata.TimeControl)
+	}
+
+	first, second := g.Moves[0], g.Moves[1]
+	if first.Elapsed != 4*time.Second || first.Clock != 4*time.Minute+58*time.Second || first.Comment != "" {
+		t.Errorf("Unexpected timing on first move: %+v", first)
+	}
+	if second.Elapsed != 12500*time.Millisecond || second.Clock != 4*time.Minute+49500*time.Millisecond {
+		t.Errorf("Unexpected timing on second move: %+v", second)
+	}
+	if second.Comment != "slow" {
+		t.Errorf("Expected remaining comment 'slow', got %q", second.Comment)
+	}
+
+	var buf bytes.Buffer
+	if err := NewWriter(&buf).Write(g); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), input[strings.Index(input, "E5"):]) {
+		t.Errorf("Round trip changed the movetext:\n%s", buf.String())
+	}
+}
+
+func TestLoggerRecordsTiming(t *testing.T) {
+	logger := NewGameLogger(t.TempDir())
+	if err := logger.StartGame("timed", "alice", "bob"); err != nil {
+		t.Fatalf("StartGame failed: %v", err)
+	}
+
+	board := game.NewUltimateBoard()
+	move := &game.Move{BoardIndex: 4, Position: 4}
+	board.MakeMove(move.BoardIndex, move.Position)
+	timing := game.MoveTiming{Elapsed: 3 * time.Second, Remaining: time.Minute}
+	if err := logger.LogMove(move, board, game.Undecided, game.Undecided, timing); err != nil {
+		t.Fatalf("LogMove failed: %v", err)
+	}
+
+	logged := logger.GetCurrentGame().Moves[0]
+	if logged.Elapsed != timing.Elapsed || logged.Clock != timing.Remaining {
+		t.Errorf("Expected timing %+v, got elapsed %v clock %v", timing, logged.Elapsed, logged.Clock)
+	}
+}
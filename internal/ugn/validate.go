@@ -0,0 +1,163 @@
+package ugn
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+)
+
+type ValidationError struct {
+	Pos     Position
+	Ply     int // 1-based move number, 0 for errors not tied to a move
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Pos.Line == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Pos, e.Message)
+}
+
+// Validate replays the game on an UltimateBoard and reports illegal moves,
+// annotations that disagree with the board, moves after the game has ended
+// and results that contradict the final position.
+func Validate(g *UGNGame) []ValidationError {
+	var errs []ValidationError
+	report := func(pos Position, ply int, format string, args ...interface{}) {
+		errs = append(errs, ValidationError{Pos: pos, Ply: ply, Message: fmt.Sprintf(format, args...)})
+	}
+
+	board := game.NewUltimateBoard()
+	replayed := validateLine(board, g.Moves, g.Variations, 0, report)
+
+	last := Position{}
+	if len(g.Moves) > 0 {
+		last = g.Moves[len(g.Moves)-1].Pos
+	}
+
+	result := g.Metadata.Result
+	switch result {
+	case "X", "O", "Draw", "*", "In Progress":
+	case "":
+		report(Position{}, 0, "missing [Result] tag")
+	default:
+		report(Position{}, 0, "unknown [Result] value %q", result)
+	}
+
+	// The final position is only known if the whole main line replayed.
+	if replayed {
+		final := boardResult(board.State)
+		if final != "" && result != "" && result != final {
+			report(last, 0, "[Result %q] does not match the final position (%s)", result, final)
+		}
+		if final == "" && (result == "Draw" || result == "X" || result == "O") && g.Metadata.Comment == "" {
+			report(last, 0, "[Result %q] on an unfinished board needs a [Comment] explaining it", result)
+		}
+	}
+
+	switch {
+	case g.ResultToken == "":
+		report(last, 0, "missing result line")
+	case result != "" && g.ResultToken != ResultLine(result):
+		report(g.ResultPos, 0, "result line %s does not match [Result %q] (expected %s)", g.ResultToken, result, ResultLine(result))
+	}
+
+	return errs
+}
+
+// validateLine plays moves on board, checking each variation from the
+// position before the move it replaces. startPly is the number of moves
+// played before the line begins. It returns false if the line could not be
+// replayed to the end.
+func validateLine(board *game.UltimateBoard, moves []UGNMove, variations []Variation, startPly int, report func(Position, int, string, ...interface{})) bool {
+	for _, v := range variations {
+		if v.Ply < 0 || v.Ply >= len(moves) {
+			report(Position{}, startPly, "variation at move %d has no main-line move to replace", startPly+v.Ply+1)
+		}
+	}
+
+	for i, m := range moves {
+		ply := startPly + i + 1
+		for _, v := range variations {
+			if v.Ply == i {
+				validateLine(board.Clone(), v.Moves, v.Variations, ply-1, report)
+			}
+		}
+		if board.State != game.Undecided {
+			report(m.Pos, ply, "move %s played after the game ended", m.ToString())
+			return false
+		}
+		beforeGameState := board.State
+		beforeSmallState := board.Boards[m.BoardIndex].State
+		move := &game.Move{BoardIndex: m.BoardIndex, Position: m.Position}
+		if err := board.MakeMove(m.BoardIndex, m.Position); err != nil {
+			report(m.Pos, ply, "illegal move %s: %s", m.ToString(), describeIllegal(board, m))
+			return false
+		}
+		expected := GenerateUGNMove(move, board, beforeGameState, beforeSmallState)
+		checkAnnotation(m, expected, report, ply)
+	}
+	return true
+}
+
+func ValidateFile(filename string) ([]ValidationError, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open UGN file: %v", err)
+	}
+	defer file.Close()
+	games, err := NewReader(file).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	var errs []ValidationError
+	for _, g := range games {
+		errs = append(errs, Validate(g)...)
+	}
+	return errs, nil
+}
+
+func checkAnnotation(got UGNMove, expected *UGNMove, report func(Position, int, string, ...interface{}), ply int) {
+	flags := []struct {
+		symbol   string
+		got      bool
+		expected bool
+	}{
+		{"!", got.SmallWin, expected.SmallWin},
+		{"/", got.SmallDraw, expected.SmallDraw},
+		{"%", got.GameDraw, expected.GameDraw},
+		{"#", got.GameWin, expected.GameWin},
+	}
+	for _, f := range flags {
+		if f.expected && !f.got {
+			report(got.Pos, ply, "move %s is missing the '%s' annotation", got.ToString(), f.symbol)
+		} else if f.got && !f.expected {
+			report(got.Pos, ply, "move %s has a '%s' annotation the position does not support", got.ToString(), f.symbol)
+		}
+	}
+}
+
+func describeIllegal(board *game.UltimateBoard, m UGNMove) string {
+	if board.ActiveBoard != -1 && board.ActiveBoard != m.BoardIndex {
+		return fmt.Sprintf("%s must play on board %c", board.CurrentTurn, 'A'+board.ActiveBoard)
+	}
+	if board.Boards[m.BoardIndex].State != game.Undecided {
+		return fmt.Sprintf("board %c is already decided", 'A'+m.BoardIndex)
+	}
+	return "cell is already occupied"
+}
+
+func boardResult(state game.BoardState) string {
+	switch state {
+	case game.XWins:
+		return "X"
+	case game.OWins:
+		return "O"
+	case game.Draw:
+		return "Draw"
+	default:
+		return ""
+	}
+}
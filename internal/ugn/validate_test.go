@@ -0,0 +1,116 @@
+package ugn
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eshahhh/ultimatetictactoe/internal/game"
+)
+
+func parseOne(t *testing.T, text string) *UGNGame {
+	t.Helper()
+	g, err := NewReader(strings.NewReader(text)).Read()
+	if err != nil {
+		t.Fatalf("Failed to parse game: %v", err)
+	}
+	return g
+}
+
+func TestValidateCleanGame(t *testing.T) {
+	g := parseOne(t, `[GameID "ok"]
+[Result "O"]
+[Comment "O wins by resignation"]
+
+E5 E1
+A5
+0-1
+`)
+	if errs := Validate(g); len(errs) != 0 {
+		t.Errorf("Expected no errors, got %v", errs)
+	}
+}
+
+func TestValidateReportsProblems(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		contains string
+		pos      string
+	}{
+		{
+			name:     "wrong board",
+			text:     "[Result \"*\"]\n\nE5 A1\n*\n",
+			contains: "must play on board E",
+			pos:      "3:4",
+		},
+		{
+			name:     "occupied cell",
+			text:     "[Result \"*\"]\n\nE5 E5\n*\n",
+			contains: "already occupied",
+			pos:      "3:4",
+		},
+		{
+			name:     "spurious annotation",
+			text:     "[Result \"*\"]\n\nE5!\n*\n",
+			contains: "'!' annotation the position does not support",
+			pos:      "3:1",
+		},
+		{
+			name:     "missing annotation",
+			text:     "[Result \"*\"]\n\nE1 A5 E2 B5 E3 C5\n*\n",
+			contains: "missing the '!' annotation",
+			pos:      "3:13",
+		},
+		{
+			name:     "result line mismatch",
+			text:     "[Result \"X\"]\n[Comment \"X wins by resignation\"]\n\nE5\n0-1\n",
+			contains: "result line 0-1 does not match",
+			pos:      "5:1",
+		},
+		{
+			name:     "missing result line",
+			text:     "[Result \"*\"]\n\nE5\n",
+			contains: "missing result line",
+			pos:      "3:1",
+		},
+	}
+
+	for _, test := range tests {
+		errs := Validate(parseOne(t, test.text))
+		found := false
+		for _, err := range errs {
+			if strings.Contains(err.Message, test.contains) {
+				found = true
+				if err.Pos.String() != test.pos {
+					t.Errorf("%s: expected error at %s, got %s", test.name, test.pos, err.Pos)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("%s: expected error containing %q, got %v", test.name, test.contains, errs)
+		}
+	}
+}
+
+func TestValidateMoveAfterGameEnd(t *testing.T) {
+	g := &UGNGame{}
+	board := game.NewUltimateBoard()
+	for board.State == game.Undecided {
+		move := board.GetValidMoves()[0]
+		beforeGameState := board.State
+		beforeSmallState := board.Boards[move.BoardIndex].State
+		board.MakeMove(move.BoardIndex, move.Position)
+		g.AddMove(*GenerateUGNMove(&move, board, beforeGameState, beforeSmallState))
+	}
+	g.SetResult(boardResult(board.State))
+	g.ResultToken = ResultLine(g.Metadata.Result)
+	if errs := Validate(g); len(errs) != 0 {
+		t.Fatalf("Expected finished game to validate, got %v", errs)
+	}
+
+	g.AddMove(UGNMove{BoardIndex: 0, Position: 0})
+	errs := Validate(g)
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "after the game ended") || errs[0].Ply != len(g.Moves) {
+		t.Errorf("Expected a single move-after-end error, got %v", errs)
+	}
+}
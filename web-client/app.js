@@ -1,6 +1,21 @@
+const SERVER = 'localhost:8080';
+
 let ws = null;
 let playerName = '';
 let gameState = null;
+let mode = 'game';
+let nextRequestId = 1;
+let leaving = false;
+let clock = null;
+let clockTimer = null;
+let muted = false;
+let resumeToken = '';
+let roomCode = '';
+
+// The resume token survives a page refresh so the game can be picked up again.
+// It is only kept while a game is in progress: there is nothing to resume in
+// the lobby, and resuming from there would land the player in matchmaking.
+const RESUME_KEY = 'uttt-resume';
 
 const BOARD_LETTERS = ['A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I'];
 
@@ -19,35 +34,80 @@ function addMessage(text, type = 'server') {
     messageList.scrollTop = messageList.scrollHeight;
 }
 
+function addChatMessage(chat) {
+    const chatList = document.getElementById('chat-list');
+    const line = document.createElement('div');
+    line.className = 'chat-message';
+    const from = document.createElement('span');
+    from.className = `chat-from ${chat.symbol.toLowerCase()}`;
+    from.textContent = `${chat.from}:`;
+    line.appendChild(from);
+    line.appendChild(document.createTextNode(chat.text));
+    chatList.appendChild(line);
+    chatList.scrollTop = chatList.scrollHeight;
+}
+
 function updateGameInfo(state) {
     if (!state) return;
 
     document.getElementById('game-id').textContent = `Game ID: ${state.game_id}`;
-    document.getElementById('your-symbol').textContent = `You: ${state.your_symbol}`;
+    document.getElementById('your-symbol').textContent = `You: ${state.your_symbol || 'Spectator'}`;
+    document.getElementById('spectator-count').textContent = `Spectators: ${state.spectators || 0}`;
     document.getElementById('player-x').textContent = `X: ${state.player_x_name}`;
     document.getElementById('player-o').textContent = `O: ${state.player_o_name}`;
     document.getElementById('current-turn').textContent = `Current Turn: ${state.current_turn}`;
 
-    let statusText = state.game_status === 'finished' ?
-        `Game Over - ${state.winner}!` :
+    const over = state.game_status === 'finished' || state.game_status === 'aborted';
+    let statusText = state.game_status === 'aborted' ? 'Game Aborted' :
+        over ? `Game Over - ${state.winner}!` :
+        mode === 'watch' ? `${state.current_turn} to move` :
         state.is_your_turn ? 'Your Turn!' : 'Opponent\'s Turn';
 
     const statusEl = document.getElementById('game-status');
     statusEl.textContent = `Status: ${statusText}`;
 
-    if (state.game_status === 'finished') {
+    const match = state.match;
+    document.getElementById('match-score').textContent = match && match.round > 1 ?
+        `Round ${match.round} - X ${match.x} : ${match.o} O` : '';
+
+    if (over) {
         statusEl.classList.add('game-finished');
         document.getElementById('find-new-game-btn').style.display = 'inline-block';
+        document.getElementById('rematch-btn').style.display = 'inline-block';
+        document.getElementById('takeback-btn').style.display = 'none';
+        removeTakebackButtons();
         document.getElementById('offer-draw-btn').style.display = 'none';
         document.getElementById('resign-btn').style.display = 'none';
         document.getElementById('refresh-btn').style.display = 'none';
     } else {
         statusEl.classList.remove('game-finished');
         document.getElementById('find-new-game-btn').style.display = 'none';
+        document.getElementById('rematch-btn').style.display = 'none';
+        removeRematchButtons();
+        document.getElementById('takeback-btn').style.display = 'inline-block';
         document.getElementById('offer-draw-btn').style.display = 'inline-block';
         document.getElementById('resign-btn').style.display = 'inline-block';
         document.getElementById('refresh-btn').style.display = 'inline-block';
     }
+
+    if (mode === 'puzzle') {
+        document.getElementById('find-new-game-btn').style.display = 'none';
+        document.getElementById('rematch-btn').style.display = 'none';
+        document.getElementById('takeback-btn').style.display = 'none';
+        document.getElementById('offer-draw-btn').style.display = 'none';
+        document.getElementById('resign-btn').style.display = 'none';
+        document.getElementById('next-puzzle-btn').style.display = 'inline-block';
+    } else {
+        document.getElementById('next-puzzle-btn').style.display = 'none';
+    }
+
+    if (mode === 'watch') {
+        document.getElementById('find-new-game-btn').style.display = 'none';
+        document.getElementById('rematch-btn').style.display = 'none';
+        document.getElementById('takeback-btn').style.display = 'none';
+        document.getElementById('offer-draw-btn').style.display = 'none';
+        document.getElementById('resign-btn').style.display = 'none';
+    }
 }
 
 function createBoard() {
@@ -181,7 +241,7 @@ function handleCellClick(boardIndex, cellIndex) {
     }
 
     const moveStr = BOARD_LETTERS[boardIndex] + (cellIndex + 1);
-    sendMessage(moveStr);
+    sendCommand('move', { move: moveStr });
 }
 
 function updateUGNNotation(moves) {
@@ -261,7 +321,10 @@ function handleMessage(data) {
         switch (msg.type) {
             case 'welcome':
                 addMessage(msg.payload.message, 'important');
-                showMatchmakingLoader(true);
+                resumeToken = msg.payload.resume_token || '';
+                if (mode === 'game') {
+                    showMatchmakingLoader(true);
+                }
                 break;
 
             case 'game_state':
@@ -271,10 +334,13 @@ function handleMessage(data) {
                 updateGameInfo(msg.payload);
                 renderBoard(msg.payload);
                 updateUGNNotation(msg.payload.ugn_moves);
+                updateClock(msg.payload);
+                rememberGame(msg.payload);
                 break;
 
             case 'move':
                 addMessage(`${msg.payload.player_name} (${msg.payload.player_symbol}) played ${msg.payload.move}`, 'info');
+                removeTakebackButtons();
                 break;
 
             case 'error':
@@ -290,6 +356,32 @@ function handleMessage(data) {
 
             case 'game_over':
                 addMessage(msg.payload.message, 'important');
+                sessionStorage.removeItem(RESUME_KEY);
+                break;
+
+            case 'room':
+                hideMatchmakingLoader();
+                roomCode = msg.payload.code;
+                addMessage(msg.payload.message, 'important');
+                document.getElementById('game-id').textContent = `Room: ${msg.payload.code}`;
+                break;
+
+            case 'disconnect_countdown':
+                addMessage(msg.payload.message, 'info');
+                break;
+
+            case 'chat':
+                addChatMessage(msg.payload);
+                break;
+
+            case 'rematch_offer':
+                addMessage(msg.payload.message, 'important');
+                showRematchButtons();
+                break;
+
+            case 'takeback_offer':
+                addMessage(msg.payload.message, 'important');
+                showTakebackButtons();
                 break;
 
             case 'draw_offer':
@@ -297,6 +389,20 @@ function handleMessage(data) {
                 showDrawOfferButtons();
                 break;
 
+            case 'ack':
+                if (!msg.payload.ok) {
+                    addMessage(`Error: ${msg.payload.error}`, 'error');
+                }
+                break;
+
+            case 'puzzle':
+                addMessage(msg.payload.message, 'important');
+                break;
+
+            case 'puzzle_result':
+                addMessage(msg.payload.message, msg.payload.solved ? 'important' : 'error');
+                break;
+
             default:
                 console.log('Unknown message type:', msg.type);
         }
@@ -331,6 +437,60 @@ function showDrawOfferButtons() {
     drawText.className = 'draw-offer-indicator pending';
 }
 
+function showRematchButtons() {
+    const controls = document.getElementById('controls');
+    removeRematchButtons();
+
+    const acceptBtn = document.createElement('button');
+    acceptBtn.textContent = 'Accept Rematch';
+    acceptBtn.className = 'rematch-response accept-draw';
+    acceptBtn.onclick = () => {
+        sendCommand('accept_rematch');
+        removeRematchButtons();
+    };
+    controls.insertBefore(acceptBtn, controls.firstChild);
+
+    const declineBtn = document.createElement('button');
+    declineBtn.textContent = 'Decline Rematch';
+    declineBtn.className = 'rematch-response decline-draw';
+    declineBtn.onclick = () => {
+        sendCommand('decline_rematch');
+        removeRematchButtons();
+    };
+    controls.insertBefore(declineBtn, controls.firstChild);
+}
+
+function showTakebackButtons() {
+    const controls = document.getElementById('controls');
+    removeTakebackButtons();
+
+    const acceptBtn = document.createElement('button');
+    acceptBtn.textContent = 'Accept Takeback';
+    acceptBtn.className = 'takeback-response accept-draw';
+    acceptBtn.onclick = () => {
+        sendCommand('accept_takeback');
+        removeTakebackButtons();
+    };
+    controls.insertBefore(acceptBtn, controls.firstChild);
+
+    const declineBtn = document.createElement('button');
+    declineBtn.textContent = 'Decline Takeback';
+    declineBtn.className = 'takeback-response decline-draw';
+    declineBtn.onclick = () => {
+        sendCommand('decline_takeback');
+        removeTakebackButtons();
+    };
+    controls.insertBefore(declineBtn, controls.firstChild);
+}
+
+function removeTakebackButtons() {
+    document.querySelectorAll('.takeback-response').forEach(btn => btn.remove());
+}
+
+function removeRematchButtons() {
+    document.querySelectorAll('.rematch-response').forEach(btn => btn.remove());
+}
+
 function removeDrawOfferButtons() {
     const buttons = document.querySelectorAll('.draw-response');
     buttons.forEach(btn => btn.remove());
@@ -339,11 +499,120 @@ function removeDrawOfferButtons() {
     drawStatus.style.display = 'none';
 }
 
-function connect() {
+// The server sends both clocks with every game_state; in between, the
+// clock of the player to move is counted down locally.
+function updateClock(state) {
+    const row = document.getElementById('clock-row');
+    if (!state.clock) {
+        clock = null;
+        row.style.display = 'none';
+        return;
+    }
+    clock = {
+        x: state.clock.x,
+        o: state.clock.o,
+        running: state.game_status === 'in_progress' ? state.current_turn : null,
+        receivedAt: Date.now(),
+    };
+    row.style.display = 'flex';
+    renderClock();
+    if (!clockTimer) {
+        clockTimer = setInterval(renderClock, 100);
+    }
+}
+
+function formatClock(ms) {
+    const tenths = Math.max(0, Math.floor(ms / 100));
+    const minutes = Math.floor(tenths / 600);
+    const seconds = Math.floor(tenths / 10) % 60;
+    const text = `${minutes}:${String(seconds).padStart(2, '0')}`;
+    return ms < 10000 ? `${text}.${tenths % 10}` : text;
+}
+
+function renderClock() {
+    if (!clock) return;
+    const elapsed = Date.now() - clock.receivedAt;
+    for (const symbol of ['x', 'o']) {
+        const running = clock.running === symbol.toUpperCase();
+        const left = clock[symbol] - (running ? elapsed : 0);
+        const el = document.getElementById(`clock-${symbol}`);
+        el.textContent = `${symbol.toUpperCase()}: ${formatClock(left)}`;
+        el.classList.toggle('running', running);
+        el.classList.toggle('low', left < 10000);
+    }
+}
+
+async function showLiveGames() {
+    const list = document.getElementById('live-games');
+    try {
+        const response = await fetch(`http://${SERVER}/games/live`);
+        const games = await response.json();
+        list.innerHTML = '';
+        if (games.length === 0) {
+            list.textContent = 'No games in progress';
+            return;
+        }
+        for (const game of games) {
+            const button = document.createElement('button');
+            button.textContent = `${game.player_x} (X) vs ${game.player_o} (O) - ` +
+                `${game.moves} moves, ${game.spectators} watching`;
+            button.onclick = () => connect('watch', game.game_id);
+            list.appendChild(button);
+        }
+    } catch (error) {
+        console.error('Failed to load live games:', error);
+        list.textContent = 'Could not load live games';
+    }
+}
+
+function joinRoom() {
+    const code = document.getElementById('room-code').value.trim();
+    if (!code) {
+        alert('Enter the room code your friend shared');
+        return;
+    }
+    connect('join', code);
+}
+
+function connect(selectedMode = 'game', gameId = '') {
     const nameInput = document.getElementById('player-name');
     playerName = nameInput.value.trim() || 'Guest';
-
-    const serverURL = `ws://localhost:8080/ws?name=${encodeURIComponent(playerName)}`;
+    mode = selectedMode;
+
+    let serverURL = `ws://${SERVER}/ws?name=${encodeURIComponent(playerName)}`;
+    const saved = JSON.parse(sessionStorage.getItem(RESUME_KEY) || 'null');
+    const timeControl = document.getElementById('time-control').value;
+    if (mode === 'puzzle') {
+        serverURL += '&mode=puzzle';
+    } else if (mode === 'watch') {
+        serverURL += `&watch=${encodeURIComponent(gameId)}`;
+    } else if (saved) {
+        // Naming the room makes a failed resume end the connection instead
+        // of joining matchmaking.
+        serverURL += `&resume=${encodeURIComponent(saved.token)}`;
+        if (saved.room) {
+            serverURL += `&room=${encodeURIComponent(saved.room)}`;
+        }
+    } else if (mode === 'room') {
+        roomCode = '';
+        const color = document.getElementById('room-color').value;
+        serverURL += `&room=new&color=${color}`;
+        if (document.getElementById('room-rated').checked) {
+            serverURL += '&rated=1';
+        }
+        if (timeControl) {
+            serverURL += `&tc=${encodeURIComponent(timeControl)}`;
+        }
+    } else if (mode === 'join') {
+        roomCode = gameId;
+        serverURL += `&room=${encodeURIComponent(gameId)}`;
+    } else {
+        roomCode = '';
+        if (timeControl) {
+            serverURL += `&tc=${encodeURIComponent(timeControl)}`;
+        }
+    }
+    leaving = false;
 
     try {
         ws = new WebSocket(serverURL);
@@ -355,6 +624,8 @@ function connect() {
             document.getElementById('game-panel').style.display = 'block';
 
             createBoard();
+            document.getElementById('chat').style.display = mode === 'puzzle' ? 'none' : 'block';
+            document.getElementById('chat-controls').style.display = mode === 'watch' ? 'none' : 'flex';
         };
 
         ws.onmessage = (event) => {
@@ -371,6 +642,10 @@ function connect() {
             updateConnectionStatus(false);
             addMessage('Disconnected from server', 'error');
             ws = null;
+            if (!leaving && mode !== 'puzzle' && mode !== 'watch' && sessionStorage.getItem(RESUME_KEY)) {
+                addMessage('Trying to reconnect...', 'info');
+                setTimeout(() => connect(mode), 2000);
+            }
         };
 
     } catch (error) {
@@ -379,21 +654,45 @@ function connect() {
     }
 }
 
+// rememberGame keeps the resume token while the player has a game in
+// progress and forgets it once the game is over.
+function rememberGame(state) {
+    if (mode === 'puzzle' || mode === 'watch' || !resumeToken) {
+        return;
+    }
+    if (state.game_status === 'in_progress' && state.your_symbol) {
+        sessionStorage.setItem(RESUME_KEY, JSON.stringify({
+            token: resumeToken,
+            name: playerName,
+            room: roomCode,
+        }));
+    } else {
+        sessionStorage.removeItem(RESUME_KEY);
+    }
+}
+
 function disconnect() {
+    leaving = true;
+    sessionStorage.removeItem(RESUME_KEY);
     if (ws && ws.readyState === WebSocket.OPEN) {
-        ws.send('quit');
+        ws.send(JSON.stringify({ type: 'quit' }));
         ws.close();
     }
     document.getElementById('connection-panel').style.display = 'block';
     document.getElementById('game-panel').style.display = 'none';
     document.getElementById('message-list').innerHTML = '';
+    document.getElementById('chat-list').innerHTML = '';
     gameState = null;
     updateConnectionStatus(false);
 }
 
-function sendMessage(message) {
+function sendCommand(type, payload) {
     if (ws && ws.readyState === WebSocket.OPEN) {
-        ws.send(message);
+        const message = { type: type, request_id: String(nextRequestId++) };
+        if (payload) {
+            message.payload = payload;
+        }
+        ws.send(JSON.stringify(message));
         console.log('Sent:', message);
     } else {
         addMessage('Not connected to server', 'error');
@@ -401,33 +700,65 @@ function sendMessage(message) {
 }
 
 function showStatus() {
-    sendMessage('status');
+    sendCommand('status');
 }
 
 function resign() {
     if (confirm('Are you sure you want to resign?')) {
-        sendMessage('R');
+        sendCommand('resign');
     }
 }
 
 function offerDraw() {
     if (confirm('Offer a draw to your opponent?')) {
-        sendMessage('DRAW');
+        sendCommand('offer_draw');
     }
 }
 
 function acceptDraw() {
-    sendMessage('ACCEPT_DRAW');
+    sendCommand('accept_draw');
     removeDrawOfferButtons();
 }
 
 function declineDraw() {
-    sendMessage('DECLINE_DRAW');
+    sendCommand('decline_draw');
     removeDrawOfferButtons();
 }
 
+function requestTakeback() {
+    sendCommand('takeback');
+}
+
+function offerRematch() {
+    sendCommand('rematch');
+}
+
+function sendChat() {
+    const input = document.getElementById('chat-input');
+    const text = input.value.trim();
+    if (!text) return;
+    sendCommand('chat', {
+        text: text,
+        spectators: document.getElementById('chat-spectators').checked,
+    });
+    input.value = '';
+}
+
+function toggleMute() {
+    muted = !muted;
+    sendCommand(muted ? 'mute' : 'unmute');
+    document.getElementById('mute-btn').textContent = muted ? 'Unmute Opponent' : 'Mute Opponent';
+}
+
+function nextPuzzle() {
+    sendCommand('next_puzzle');
+}
+
 function findNewGame() {
+    leaving = true;
+    sessionStorage.removeItem(RESUME_KEY);
     if (ws && ws.readyState === WebSocket.OPEN) {
+        ws.send(JSON.stringify({ type: 'quit' }));
         ws.close();
     }
 
@@ -435,6 +766,7 @@ function findNewGame() {
     document.getElementById('ultimate-board').innerHTML = '';
     document.getElementById('ugn-moves').innerHTML = '';
     document.getElementById('message-list').innerHTML = '';
+    document.getElementById('chat-list').innerHTML = '';
 
     setTimeout(() => {
         connect();
@@ -460,4 +792,16 @@ document.addEventListener('DOMContentLoaded', () => {
             connect();
         }
     });
+
+    document.getElementById('chat-input').addEventListener('keypress', (e) => {
+        if (e.key === 'Enter') {
+            sendChat();
+        }
+    });
+
+    const saved = JSON.parse(sessionStorage.getItem(RESUME_KEY) || 'null');
+    if (saved) {
+        nameInput.value = saved.name;
+        connect();
+    }
 });